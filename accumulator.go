@@ -0,0 +1,136 @@
+package dominantcolor
+
+import "image"
+
+// Rate at which frames are blended into the background model used for
+// frame differencing. The model adapts to changes of the scene in about
+// 1/backgroundRate frames.
+const backgroundRate = 0.05
+
+// Accumulator aggregates the colors of a stream of images, such as the
+// frames of a video, into a single palette without keeping the images in
+// memory. It is not safe for concurrent use.
+type Accumulator struct {
+	o *options
+
+	// Total weight of each distinct color, in the order of first
+	// occurrence to keep the results deterministic.
+	index  map[vector]int
+	colors []point
+	total  float64
+
+	// Lab colors of the background model used for frame differencing.
+	background       []lab
+	backgroundBounds image.Rectangle
+}
+
+// NewAccumulator returns an empty Accumulator analyzing images with the
+// given options.
+func NewAccumulator(opts ...Option) *Accumulator {
+	return &Accumulator{
+		o:     newOptions(opts),
+		index: make(map[vector]int),
+	}
+}
+
+// Add accumulates the colors of img. Invalid images are ignored.
+//
+// With WithFrameDifferencing, only the pixels that changed compared to the
+// previous images are accumulated. They are compared to a background model
+// averaging the previous images rather than to the last image alone, so the
+// background revealed behind a moving subject is not counted as a change.
+// The first image, and any image whose size differs from the previous one,
+// only initializes the model.
+func (a *Accumulator) Add(img image.Image) {
+	if ValidateInput(img, 0) != nil {
+		return
+	}
+	mask := newWeightMask(img, a.o)
+	img = a.o.resize(img)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	points := imagePoints(img, mask.scaled(bounds), a.o)
+	if a.o.blur > 0 {
+		blurPoints(points, width, height, a.o.blur)
+	}
+	if a.o.edgeWeight < 1 {
+		downweightEdges(points, width, height, a.o.edgeWeight)
+	}
+	if a.o.frameDiff && !a.keepChanged(points, bounds) {
+		return
+	}
+	if a.o.superpixels > 0 {
+		segmentSuperpixels(points, width, height, a.o.superpixels)
+	}
+	a.total += float64(len(points))
+	for _, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		// Positions are meaningless across frames.
+		p.v[3], p.v[4] = 0, 0
+		if i, ok := a.index[p.v]; ok {
+			a.colors[i].weight += p.weight
+			continue
+		}
+		a.index[p.v] = len(a.colors)
+		a.colors = append(a.colors, p)
+	}
+}
+
+// keepChanged zeroes the weight of points that match the background model
+// and blends the current frame into the model. It returns false if there
+// is no model of the same size to compare with yet.
+func (a *Accumulator) keepChanged(points []point, bounds image.Rectangle) bool {
+	labs := make([]lab, len(points))
+	for i, p := range points {
+		labs[i] = rgbToLab(uint8(p.v[0]), uint8(p.v[1]), uint8(p.v[2]))
+	}
+	if a.background == nil || a.backgroundBounds != bounds {
+		a.background, a.backgroundBounds = labs, bounds
+		return false
+	}
+	for i := range points {
+		bg := &a.background[i]
+		if deltaE(labs[i], *bg) <= a.o.diffTolerance {
+			points[i].weight = 0
+		}
+		bg.L += backgroundRate * (labs[i].L - bg.L)
+		bg.A += backgroundRate * (labs[i].A - bg.A)
+		bg.B += backgroundRate * (labs[i].B - bg.B)
+	}
+	return true
+}
+
+// Palette returns the dominant colors of all accumulated images with
+// their weights, as FindWeight does for a single image. Weights are
+// fractions of the total area of the accumulated images.
+func (a *Accumulator) Palette(nClusters int) []Color {
+	if nClusters <= 0 {
+		nClusters = nClustersDefault
+	}
+	if len(a.colors) == 0 || nClusters > maxClusters {
+		return []Color{}
+	}
+	// The accumulated colors are clustered as a single row of points, and
+	// the options depending on the position of pixels were already applied
+	// by Add.
+	o := *a.o
+	o.edgeWeight = 1
+	o.blur = 0
+	o.superpixels = 0
+	o.regionWeight = false
+	points := make([]point, len(a.colors))
+	copy(points, a.colors)
+	clusters := clusterPoints(points, len(points), 1, nClusters, nIterations, &o)
+	return clusterColors(clusters, a.total)
+}
+
+// Reset discards all accumulated colors and the background model.
+func (a *Accumulator) Reset() {
+	a.index = make(map[vector]int)
+	a.colors = nil
+	a.total = 0
+	a.background = nil
+	a.backgroundBounds = image.Rectangle{}
+}
@@ -0,0 +1,35 @@
+package dominantcolor
+
+import "image"
+
+// Largest number of colors of FindAdaptive by default.
+const adaptiveMaxColors = 16
+
+// FindAdaptive returns as many dominant colors as needed for the image to
+// be reconstructed from them with a mean color difference, as reported by
+// Validate, of at most maxDeltaE, and no more. The number of colors is
+// increased from one until the target is reached or there are maxColors
+// colors, 16 if maxColors is less than or equal to 0. Flat images need
+// few colors and detailed photos many. Colors and weights are as returned
+// by FindWeight. Each additional color costs a full analysis.
+func FindAdaptive(img image.Image, maxDeltaE float64, maxColors int, opts ...Option) []Color {
+	if maxColors <= 0 {
+		maxColors = adaptiveMaxColors
+	}
+	if ValidateInput(img, maxColors) != nil {
+		return []Color{}
+	}
+	var colors []Color
+	for k := 1; k <= maxColors; k++ {
+		colors = FindWeight(img, k, opts...)
+		if len(colors) < k {
+			break
+		}
+		// The difference is NaN for images without pixels to analyze,
+		// which more colors cannot improve.
+		if d := Validate(img, colors, opts...).MeanDeltaE; !(d > maxDeltaE) {
+			break
+		}
+	}
+	return colors
+}
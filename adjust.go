@@ -0,0 +1,78 @@
+package dominantcolor
+
+import (
+	"image/color"
+	"math"
+)
+
+// The following functions adjust colors in CIE LCh, so that lightness,
+// chroma and hue change independently and by perceptually even steps.
+// Results outside of the sRGB gamut are brought back into it by lowering
+// their chroma, which keeps their lightness and hue. Alpha is kept.
+
+// Lighten returns c with its CIE lightness, from 0 to 100, raised by
+// amount.
+func Lighten(c color.RGBA, amount float64) color.RGBA {
+	return adjustLCh(c, func(v *lch) { v.L += amount })
+}
+
+// Darken returns c with its CIE lightness, from 0 to 100, lowered by
+// amount.
+func Darken(c color.RGBA, amount float64) color.RGBA {
+	return Lighten(c, -amount)
+}
+
+// Saturate returns c with its chroma raised by amount, or lowered if
+// amount is negative. Chroma ranges from 0 for grays to above 100 for the
+// most vivid colors.
+func Saturate(c color.RGBA, amount float64) color.RGBA {
+	return adjustLCh(c, func(v *lch) { v.C = math.Max(0, v.C+amount) })
+}
+
+// Rotate returns c with its hue rotated by degrees. Grays are unchanged.
+func Rotate(c color.RGBA, degrees float64) color.RGBA {
+	return adjustLCh(c, func(v *lch) { v.H = normalizeHue(v.H + degrees) })
+}
+
+// adjustLCh applies adjust to the LCh coordinates of c, undoing the alpha
+// premultiplication of c for the adjustment.
+func adjustLCh(c color.RGBA, adjust func(*lch)) color.RGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	v := rgbToLab(n.R, n.G, n.B).LCh()
+	adjust(&v)
+	v.L = math.Max(0, math.Min(100, v.L))
+	r := fitGamut(v).Lab().RGBA()
+	return color.RGBAModel.Convert(color.NRGBA{R: r.R, G: r.G, B: r.B, A: c.A}).(color.RGBA)
+}
+
+// fitGamut returns c with its chroma lowered as little as needed for it to
+// be inside the sRGB gamut.
+func fitGamut(c lch) lch {
+	if inGamut(c.Lab()) {
+		return c
+	}
+	lo, hi := 0.0, c.C
+	for i := 0; i < 24; i++ {
+		c.C = (lo + hi) / 2
+		if inGamut(c.Lab()) {
+			lo = c.C
+		} else {
+			hi = c.C
+		}
+	}
+	c.C = lo
+	return c
+}
+
+// inGamut returns whether c is inside the sRGB gamut, allowing for the
+// rounding of channels.
+func inGamut(c lab) bool {
+	r, g, b := c.linear()
+	const eps = 0.5 / 0xff / 12.92
+	for _, v := range []float64{r, g, b} {
+		if v < -eps || v > 1+eps {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+)
+
+// Ambient turns the dominant colors of a stream of frames into a smoothly
+// changing color suited to ambient lighting, such as LEDs behind a screen.
+// It keeps a memory of the colors of recent frames, where older frames
+// fade out, and moves its color towards the blend of the memory no faster
+// than a maximum rate, so that cuts between scenes do not flash the
+// lights. It is not safe for concurrent use.
+type Ambient struct {
+	halfLife time.Duration
+	maxRate  float64
+	opts     []Option
+
+	// Blend of the colors of recent frames, and the current color.
+	memory  lab
+	current lab
+	started bool
+}
+
+// NewAmbient returns an Ambient where the weight of a frame in the memory
+// halves every halfLife, and the color changes by at most maxRate delta-E
+// per second. Frames are analyzed with the given options. A zero halfLife
+// keeps no memory and a zero maxRate does not limit the rate.
+func NewAmbient(halfLife time.Duration, maxRate float64, opts ...Option) *Ambient {
+	return &Ambient{halfLife: halfLife, maxRate: maxRate, opts: opts}
+}
+
+// Update adds the next frame, elapsed after the previous one, and returns
+// the ambient color. The first frame sets the color directly. Invalid
+// frames leave the memory unchanged.
+func (a *Ambient) Update(frame image.Image, elapsed time.Duration) color.RGBA {
+	if ValidateInput(frame, 0) == nil {
+		c := Find(frame, a.opts...)
+		a.add(rgbToLab(c.R, c.G, c.B), elapsed)
+	}
+	return a.Color()
+}
+
+// add blends c into the memory and moves the current color towards it.
+func (a *Ambient) add(c lab, elapsed time.Duration) {
+	if !a.started {
+		a.memory, a.current, a.started = c, c, true
+		return
+	}
+	// Weight of the new frame, so that the weight of the memory halves
+	// every half life.
+	w := 1.0
+	if a.halfLife > 0 {
+		w = 1 - math.Exp2(-elapsed.Seconds()/a.halfLife.Seconds())
+	}
+	a.memory = lab{
+		L: a.memory.L + w*(c.L-a.memory.L),
+		A: a.memory.A + w*(c.A-a.memory.A),
+		B: a.memory.B + w*(c.B-a.memory.B),
+	}
+	step := 1.0
+	if d := deltaE(a.current, a.memory); a.maxRate > 0 && d > 0 {
+		step = math.Min(1, a.maxRate*elapsed.Seconds()/d)
+	}
+	a.current = lab{
+		L: a.current.L + step*(a.memory.L-a.current.L),
+		A: a.current.A + step*(a.memory.A-a.current.A),
+		B: a.current.B + step*(a.memory.B-a.current.B),
+	}
+}
+
+// Color returns the current ambient color, or transparent black before
+// the first valid frame.
+func (a *Ambient) Color() color.RGBA {
+	if !a.started {
+		return color.RGBA{}
+	}
+	return a.current.RGBA()
+}
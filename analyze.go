@@ -0,0 +1,129 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Number of bins of the luminance histogram of a Report.
+const luminanceBins = 16
+
+// Report holds statistics of an image computed by Analyze.
+type Report struct {
+	// Dominant colors, as returned by FindWeight with 4 clusters.
+	Palette []Color
+	// Mean color of the pixels, weighted by their alpha.
+	Average color.RGBA
+	// Fraction of the opaque area of the image in each of 16 equal ranges
+	// of Rec. 709 luma, from dark to light.
+	Luminance [luminanceBins]float64
+	// Colorfulness of the image as defined by Hasler and Süsstrunk, from 0
+	// for grayscale images to above 100 for very colorful ones.
+	Colorfulness float64
+	// Fraction of the image that is transparent, 1 minus the mean alpha.
+	Transparency float64
+	// Split of the palette into warm, cool and neutral colors.
+	Temperature Temperature
+}
+
+// Analyze returns the dominant colors of img along with other statistics
+// computed in the same pass over the pixels, for services that would
+// otherwise decode and scan images several times. Like the dominant
+// colors, the statistics are computed on the working image shrunk as by
+// WorkingImage. The options apply to the palette; the other statistics
+// count every pixel weighted by alpha, regardless of masks and ignored
+// colors. It returns a zero Report if the input is rejected by
+// ValidateInput.
+func Analyze(img image.Image, opts ...Option) Report {
+	if ValidateInput(img, nClustersDefault) != nil {
+		return Report{}
+	}
+	o := newOptions(opts)
+	mask := newWeightMask(img, o)
+	end := o.span(PhaseResize)
+	img = o.resize(img)
+	end()
+
+	var s imageStats
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	end = o.span(PhasePixels)
+	points := visitImagePoints(img, mask.scaled(bounds), o, s.add)
+	end()
+	clusters := clusterPoints(points, width, height, nClustersDefault, nIterations, o)
+	palette := clusterColors(clusters, float64(width)*float64(height))
+	r := s.report(float64(width) * float64(height))
+	r.Palette = palette
+	r.Temperature = SplitTemperature(palette)
+	return r
+}
+
+// imageStats accumulates the statistics of a Report over pixels.
+type imageStats struct {
+	// Total alpha, from 0 to 1 per pixel.
+	alpha float64
+	// Sums of the alpha-premultiplied channels, from 0 to 1.
+	r, g, b float64
+	// Sums and sums of squares of the opponent channels used by the
+	// colorfulness, weighted by alpha.
+	rg, yb, rg2, yb2 float64
+	luminance        [luminanceBins]float64
+}
+
+// add adds a pixel with the given alpha-premultiplied color.
+func (s *imageStats) add(r, g, b, a uint32) {
+	if a == 0 {
+		return
+	}
+	// Images with invalid premultiplied colors, such as an *image.RGBA
+	// with channels above alpha, are read as the closest valid colors.
+	if r > a {
+		r = a
+	}
+	if g > a {
+		g = a
+	}
+	if b > a {
+		b = a
+	}
+	w := float64(a) / 0xffff
+	s.alpha += w
+	s.r += float64(r) / 0xffff
+	s.g += float64(g) / 0xffff
+	s.b += float64(b) / 0xffff
+	// Straight channels from 0 to 255.
+	rs := float64(r) / float64(a) * 0xff
+	gs := float64(g) / float64(a) * 0xff
+	bs := float64(b) / float64(a) * 0xff
+	rg, yb := rs-gs, (rs+gs)/2-bs
+	s.rg += rg * w
+	s.yb += yb * w
+	s.rg2 += rg * rg * w
+	s.yb2 += yb * yb * w
+	luma := (0.2126*rs + 0.7152*gs + 0.0722*bs) / 0x100
+	s.luminance[int(luma*luminanceBins)] += w
+}
+
+// report returns the statistics of the pixels added to s out of the given
+// number of pixels.
+func (s *imageStats) report(pixels float64) Report {
+	r := Report{Transparency: 1 - s.alpha/pixels}
+	if s.alpha == 0 {
+		return r
+	}
+	r.Average = color.RGBA{
+		R: clampChannel(s.r / s.alpha * 0xff),
+		G: clampChannel(s.g / s.alpha * 0xff),
+		B: clampChannel(s.b / s.alpha * 0xff),
+		A: 0xff,
+	}
+	for i, w := range s.luminance {
+		r.Luminance[i] = w / s.alpha
+	}
+	mrg, myb := s.rg/s.alpha, s.yb/s.alpha
+	vrg := math.Max(0, s.rg2/s.alpha-mrg*mrg)
+	vyb := math.Max(0, s.yb2/s.alpha-myb*myb)
+	r.Colorfulness = math.Sqrt(vrg+vyb) + 0.3*math.Hypot(mrg, myb)
+	return r
+}
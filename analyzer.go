@@ -0,0 +1,102 @@
+package dominantcolor
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"time"
+)
+
+// ErrQueueFull is returned by Analyzer when too many requests are waiting.
+var ErrQueueFull = errors.New("dominantcolor: analyzer queue full")
+
+// Analyzer bounds the resources used to analyze images in a service
+// embedding the package, so that a burst of large uploads cannot starve
+// it: at most a given number of images are analyzed at once, a limited
+// number of requests wait for their turn and the others are rejected, and
+// requests are abandoned after a timeout. It is safe for concurrent use.
+type Analyzer struct {
+	running chan struct{}
+	waiting chan struct{}
+	timeout time.Duration
+	opts    []Option
+}
+
+// NewAnalyzer returns an Analyzer running at most maxConcurrent analyses
+// at once, with at most maxQueued more requests waiting, and finding
+// colors with the given options. If timeout is positive, each request is
+// abandoned when it takes longer, including the time spent waiting.
+// maxConcurrent is at least 1.
+func NewAnalyzer(maxConcurrent, maxQueued int, timeout time.Duration, opts ...Option) *Analyzer {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &Analyzer{
+		running: make(chan struct{}, maxConcurrent),
+		waiting: make(chan struct{}, maxConcurrent+maxQueued),
+		timeout: timeout,
+		opts:    opts,
+	}
+}
+
+// FindWeight is like the FindWeight function, within the limits of the
+// Analyzer. It returns ErrQueueFull if the queue is full, the error of ctx
+// if ctx is done or the timeout expires first, or the error of
+// ValidateInput for invalid inputs. An abandoned analysis keeps its slot
+// until it completes, so that the number of analyses running at once is
+// never exceeded.
+func (a *Analyzer) FindWeight(ctx context.Context, img image.Image, nClusters int) ([]Color, error) {
+	if err := ValidateInput(img, nClusters); err != nil {
+		return nil, err
+	}
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	select {
+	case a.waiting <- struct{}{}:
+	default:
+		return nil, ErrQueueFull
+	}
+	select {
+	case a.running <- struct{}{}:
+	case <-ctx.Done():
+		<-a.waiting
+		return nil, ctx.Err()
+	}
+	// Do not start if ctx was done while the slot was free.
+	if err := ctx.Err(); err != nil {
+		<-a.running
+		<-a.waiting
+		return nil, err
+	}
+	result := make(chan []Color, 1)
+	go func() {
+		defer func() {
+			<-a.running
+			<-a.waiting
+		}()
+		result <- FindWeight(img, nClusters, a.opts...)
+	}()
+	select {
+	case colors := <-result:
+		return colors, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Find is like the Find function, within the limits of the Analyzer, as
+// described for FindWeight.
+func (a *Analyzer) Find(ctx context.Context, img image.Image) (color.RGBA, error) {
+	colors, err := a.FindWeight(ctx, img, nClustersDefault)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return newOptions(a.opts).pick(colors), nil
+}
@@ -0,0 +1,72 @@
+package dominantcolor
+
+// Archetype is a label describing the overall look of a palette.
+type Archetype string
+
+// Palette archetypes returned by Classify.
+const (
+	Pastel       Archetype = "pastel"
+	Vivid        Archetype = "vivid"
+	Dark         Archetype = "dark"
+	Muted        Archetype = "muted"
+	HighContrast Archetype = "high-contrast"
+)
+
+// Thresholds on HSL saturation and lightness of palette archetypes.
+const (
+	darkLightness      = 0.3
+	pastelLightness    = 0.7
+	pastelSaturation   = 0.2
+	vividSaturation    = 0.6
+	mutedSaturation    = 0.25
+	highContrastSpread = 0.6
+	significantWeight  = 0.05
+)
+
+// Classify returns the archetypes matching a weighted palette, such as one
+// returned by FindWeight, based on the weighted mean saturation and
+// lightness of its colors and on the lightness spread of colors weighing at
+// least 5% of the palette. A palette may match several archetypes or none.
+func Classify(colors []Color) []Archetype {
+	var total, saturation, lightness float64
+	minL, maxL := 1.0, 0.0
+	for _, c := range colors {
+		total += c.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	for _, c := range colors {
+		_, s, l := hsl(c.RGBA)
+		saturation += s * c.Weight
+		lightness += l * c.Weight
+		if c.Weight/total >= significantWeight {
+			if l < minL {
+				minL = l
+			}
+			if l > maxL {
+				maxL = l
+			}
+		}
+	}
+	saturation /= total
+	lightness /= total
+
+	var archetypes []Archetype
+	if lightness > pastelLightness && saturation > pastelSaturation {
+		archetypes = append(archetypes, Pastel)
+	}
+	if saturation > vividSaturation && lightness >= darkLightness && lightness <= pastelLightness {
+		archetypes = append(archetypes, Vivid)
+	}
+	if lightness < darkLightness {
+		archetypes = append(archetypes, Dark)
+	}
+	if saturation < mutedSaturation {
+		archetypes = append(archetypes, Muted)
+	}
+	if maxL-minL >= highContrastSpread {
+		archetypes = append(archetypes, HighContrast)
+	}
+	return archetypes
+}
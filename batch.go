@@ -0,0 +1,68 @@
+package dominantcolor
+
+import "image"
+
+// FindRegions returns the dominant colors with their weights in each of
+// regions, given in the coordinates of img, as FindWeight would return for
+// the corresponding sub-image. The image is resized and converted only
+// once, which is much faster than analyzing each region separately when
+// labeling many objects found by a detector. Weights are relative to the
+// area of each region.
+func FindRegions(img image.Image, regions []image.Rectangle, nClusters int, opts ...Option) [][]Color {
+	palettes := make([][]Color, len(regions))
+	for i := range palettes {
+		palettes[i] = []Color{}
+	}
+	if nClusters <= 0 {
+		nClusters = nClustersDefault
+	}
+	if ValidateInput(img, nClusters) != nil {
+		return palettes
+	}
+	o := newOptions(opts)
+	src := img.Bounds()
+	mask := newWeightMask(img, o)
+	img = o.resize(img)
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	points := imagePoints(img, mask.scaled(bounds), o)
+
+	for i, r := range regions {
+		r = scaleRect(r.Intersect(src), src, bounds).Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+		sub := make([]point, 0, r.Dx()*r.Dy())
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			offset := (y-bounds.Min.Y)*width - bounds.Min.X
+			sub = append(sub, points[offset+r.Min.X:offset+r.Max.X]...)
+		}
+		clusters := clusterPoints(sub, r.Dx(), r.Dy(), nClusters, nIterations, o)
+		palettes[i] = clusterColors(clusters, float64(len(sub)))
+	}
+	return palettes
+}
+
+// scaleRect maps r from the rectangle from to the rectangle to, rounding
+// outwards so that no pixel of r is lost.
+func scaleRect(r, from, to image.Rectangle) image.Rectangle {
+	if from == to {
+		return r
+	}
+	floor := func(v, fromMin, fromSize, toMin, toSize int) int {
+		return toMin + (v-fromMin)*toSize/fromSize
+	}
+	ceil := func(v, fromMin, fromSize, toMin, toSize int) int {
+		return toMin + ((v-fromMin)*toSize+fromSize-1)/fromSize
+	}
+	return image.Rectangle{
+		Min: image.Point{
+			X: floor(r.Min.X, from.Min.X, from.Dx(), to.Min.X, to.Dx()),
+			Y: floor(r.Min.Y, from.Min.Y, from.Dy(), to.Min.Y, to.Dy()),
+		},
+		Max: image.Point{
+			X: ceil(r.Max.X, from.Min.X, from.Dx(), to.Min.X, to.Dx()),
+			Y: ceil(r.Max.Y, from.Min.Y, from.Dy(), to.Min.Y, to.Dy()),
+		},
+	}
+}
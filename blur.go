@@ -0,0 +1,57 @@
+package dominantcolor
+
+import "math"
+
+// blurPoints replaces the color of each point of the row-major grid of
+// points with the mean color of the points within radius of it
+// horizontally and vertically. Points with zero weight neither contribute
+// nor change. The box filter is applied separately to rows and columns.
+func blurPoints(points []point, width, height, radius int) {
+	if radius <= 0 || width*height == 0 {
+		return
+	}
+	colors := make([][3]float64, len(points))
+	for i, p := range points {
+		colors[i] = [3]float64{p.v[0], p.v[1], p.v[2]}
+	}
+	pass := func(n, stride, count, step int) {
+		sums := make([][3]float64, n)
+		for line := 0; line < count; line++ {
+			start := line * step
+			for i := 0; i < n; i++ {
+				sums[i] = [3]float64{}
+				var weight float64
+				for j := i - radius; j <= i+radius; j++ {
+					if j < 0 || j >= n {
+						continue
+					}
+					k := start + j*stride
+					if points[k].weight == 0 {
+						continue
+					}
+					for c := range sums[i] {
+						sums[i][c] += colors[k][c]
+					}
+					weight++
+				}
+				if weight > 0 {
+					for c := range sums[i] {
+						sums[i][c] /= weight
+					}
+				}
+			}
+			for i := 0; i < n; i++ {
+				if k := start + i*stride; points[k].weight != 0 {
+					colors[k] = sums[i]
+				}
+			}
+		}
+	}
+	pass(width, 1, height, width)
+	pass(height, width, width, 1)
+	for i := range points {
+		for c := 0; c < 3; c++ {
+			points[i].v[c] = math.Floor(colors[i][c])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// Number of clusters CategoricalPalette draws colors from.
+	categoricalClusters = 16
+	// Smallest color difference, in CIE76 delta-E, at which
+	// CategoricalPalette still prefers colors of the image to derived ones.
+	categoricalDeltaE = 20
+)
+
+// CategoricalPalette returns n colors, as distinguishable from each other as
+// possible, themed after img, for charts and dashboards matching a hero
+// image. The heaviest dominant color comes first. The following colors are
+// picked among the dominant colors of img, each time the one farthest from
+// the colors already picked. When the remaining dominant colors are closer
+// than a delta-E of 20 to a picked color, the farthest color among them and
+// their hue rotations, lighter and darker variants is picked instead. It
+// returns nil for invalid images.
+func CategoricalPalette(img image.Image, n int, opts ...Option) []color.RGBA {
+	colors := FindWeight(img, categoricalClusters, opts...)
+	if len(colors) == 0 || n <= 0 {
+		return nil
+	}
+	var own, derived []color.RGBA
+	for _, c := range colors {
+		own = append(own, c.RGBA)
+		for hue := 30.0; hue < 360; hue += 30 {
+			derived = append(derived, Rotate(c.RGBA, hue))
+		}
+		derived = append(derived, Lighten(c.RGBA, 25), Darken(c.RGBA, 25))
+	}
+	palette := []color.RGBA{own[0]}
+	for len(palette) < n {
+		c, d := farthestColor(own, palette)
+		if d < categoricalDeltaE {
+			if dc, dd := farthestColor(derived, palette); dd > d {
+				c = dc
+			}
+		}
+		palette = append(palette, c)
+	}
+	return palette
+}
+
+// farthestColor returns the first of candidates with the largest color
+// difference to the nearest color of palette, and that difference.
+func farthestColor(candidates, palette []color.RGBA) (color.RGBA, float64) {
+	var farthest color.RGBA
+	farthestDist := -1.0
+	for _, c := range candidates {
+		dist := math.Inf(1)
+		for _, p := range palette {
+			dist = math.Min(dist, DeltaE(c, p))
+		}
+		if dist > farthestDist {
+			farthest, farthestDist = c, dist
+		}
+	}
+	return farthest, farthestDist
+}
@@ -0,0 +1,86 @@
+package dominantcolor
+
+import "encoding/json"
+
+// Largest number of embeds of a Discord message.
+const maxDiscordEmbeds = 10
+
+type slackPayload struct {
+	Text        string            `json:"text,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type discordPayload struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Color       int    `json:"color"`
+	Description string `json:"description"`
+}
+
+// SlackPayload returns a Slack message, in the JSON accepted by incoming
+// webhooks and chat.postMessage, showing each color as an attachment of
+// that color with a Block Kit section holding its hex code and percentage.
+// text, if not empty, is the message shown above the colors and in
+// notifications.
+func SlackPayload(text string, colors []Color) ([]byte, error) {
+	payload := slackPayload{Text: text, Attachments: []slackAttachment{}}
+	for i, label := range chatLabels(colors) {
+		payload.Attachments = append(payload.Attachments, slackAttachment{
+			Color: Hex(colors[i].RGBA),
+			Blocks: []slackBlock{{
+				Type: "section",
+				Text: slackText{Type: "mrkdwn", Text: label},
+			}},
+		})
+	}
+	return json.Marshal(payload)
+}
+
+// DiscordPayload returns a Discord message, in the JSON accepted by
+// webhooks, showing each color as an embed of that color holding its hex
+// code and percentage. Only the first 10 colors are included, as Discord
+// rejects messages with more embeds. content, if not empty, is the message
+// shown above the colors.
+func DiscordPayload(content string, colors []Color) ([]byte, error) {
+	labels := chatLabels(colors)
+	if len(labels) > maxDiscordEmbeds {
+		labels = labels[:maxDiscordEmbeds]
+	}
+	payload := discordPayload{Content: content, Embeds: []discordEmbed{}}
+	for i, label := range labels {
+		c := colors[i]
+		payload.Embeds = append(payload.Embeds, discordEmbed{
+			Color:       int(c.R)<<16 | int(c.G)<<8 | int(c.B),
+			Description: label,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+// chatLabels returns the Markdown label of each color: its hex code and,
+// if the colors have weights, its percentage.
+func chatLabels(colors []Color) []string {
+	labels := percentLabels(colors)
+	for i, c := range colors {
+		labels[i] = "`" + Hex(c.RGBA) + "`" + labels[i]
+	}
+	return labels
+}
@@ -0,0 +1,31 @@
+package dominantcolor
+
+// flatCentroids returns the centroids of the clusters laid out
+// contiguously, as consumed by closestCentroid.
+func (a kMeanClusterGroup) flatCentroids() []float64 {
+	centroids := make([]float64, 0, len(a)*nFeatures)
+	for _, c := range a {
+		centroids = append(centroids, c.centroid[:]...)
+	}
+	return centroids
+}
+
+// closestCentroidGeneric returns the index of the centroid closest to v
+// among the contiguous centroids, or -1 if there are none. The squared
+// distance is summed in the same order as the assembly implementations so
+// that all produce identical results. The conversions round each product
+// so that the compiler does not fuse them with the additions.
+func closestCentroidGeneric(centroids []float64, v *vector) int {
+	closest := -1
+	var best float64
+	for i := 0; i+nFeatures <= len(centroids); i += nFeatures {
+		c := centroids[i : i+nFeatures : i+nFeatures]
+		d0, d1, d2, d3, d4 := c[0]-v[0], c[1]-v[1], c[2]-v[2], c[3]-v[3], c[4]-v[4]
+		d := (float64(d0*d0) + float64(d2*d2)) + (float64(d1*d1) + float64(d3*d3)) + float64(d4*d4)
+		if closest < 0 || d < best {
+			best = d
+			closest = i / nFeatures
+		}
+	}
+	return closest
+}
@@ -0,0 +1,9 @@
+//go:build !purego
+
+package dominantcolor
+
+// closestCentroid is implemented with SSE2 instructions, which are
+// available on every amd64 processor.
+//
+//go:noescape
+func closestCentroid(centroids []float64, v *vector) int
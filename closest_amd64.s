@@ -0,0 +1,66 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func closestCentroid(centroids []float64, v *vector) int
+TEXT ·closestCentroid(SB), NOSPLIT, $0-40
+	MOVQ centroids_base+0(FP), SI
+	MOVQ centroids_len+8(FP), CX
+	MOVQ v+24(FP), DI
+
+	// X0 = v[0:2], X1 = v[2:4], X2 = v[4]
+	MOVUPD 0(DI), X0
+	MOVUPD 16(DI), X1
+	MOVSD  32(DI), X2
+
+	// DX holds the index of the best centroid so far and X7 its distance.
+	// Like closestCentroidGeneric, the first centroid is always taken so
+	// that the result is never -1 unless there are no centroids, even when
+	// the distances are NaN or +Inf.
+	MOVQ $-1, DX
+	XORQ BX, BX
+
+loop:
+	CMPQ CX, $5
+	JLT  done
+
+	MOVUPD 0(SI), X3
+	SUBPD  X0, X3
+	MULPD  X3, X3
+	MOVUPD 16(SI), X4
+	SUBPD  X1, X4
+	MULPD  X4, X4
+
+	// X3 = [d0*d0 + d2*d2, d1*d1 + d3*d3]
+	ADDPD X4, X3
+
+	// Horizontal sum of X3.
+	MOVAPD   X3, X6
+	UNPCKHPD X6, X6
+	ADDSD    X6, X3
+
+	MOVSD 32(SI), X5
+	SUBSD X2, X5
+	MULSD X5, X5
+	ADDSD X5, X3
+
+	// Keep the distance if it is the first one or smaller than the best
+	// one. Unordered comparisons with NaN are not smaller.
+	TESTQ   DX, DX
+	JS      take
+	UCOMISD X3, X7
+	JLS     next
+
+take:
+	MOVSD X3, X7
+	MOVQ  BX, DX
+
+next:
+	ADDQ $40, SI
+	INCQ BX
+	SUBQ $5, CX
+	JMP  loop
+
+done:
+	MOVQ DX, ret+32(FP)
+	RET
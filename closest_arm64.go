@@ -0,0 +1,9 @@
+//go:build !purego
+
+package dominantcolor
+
+// closestCentroid is implemented with scalar floating-point instructions,
+// which are available on every arm64 processor.
+//
+//go:noescape
+func closestCentroid(centroids []float64, v *vector) int
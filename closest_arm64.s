@@ -0,0 +1,73 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func closestCentroid(centroids []float64, v *vector) int
+TEXT ·closestCentroid(SB), NOSPLIT, $0-40
+	MOVD centroids_base+0(FP), R0
+	MOVD centroids_len+8(FP), R1
+	MOVD v+24(FP), R2
+
+	// F0-F4 = v[0:5]
+	FMOVD 0(R2), F0
+	FMOVD 8(R2), F1
+	FMOVD 16(R2), F2
+	FMOVD 24(R2), F3
+	FMOVD 32(R2), F4
+
+	// R3 holds the index of the best centroid so far and F16 its distance.
+	// Like closestCentroidGeneric, the first centroid is always taken so
+	// that the result is never -1 unless there are no centroids, even when
+	// the distances are NaN or +Inf.
+	MOVD $-1, R3
+	MOVD $0, R4
+
+loop:
+	CMP $5, R1
+	BLT done
+
+	FMOVD 0(R0), F5
+	FMOVD 8(R0), F6
+	FMOVD 16(R0), F7
+	FMOVD 24(R0), F8
+	FMOVD 32(R0), F9
+	FSUBD F0, F5
+	FSUBD F1, F6
+	FSUBD F2, F7
+	FSUBD F3, F8
+	FSUBD F4, F9
+
+	// Separate multiplications and additions, without fused multiply-add,
+	// sum the squares in the same order as closestCentroidGeneric:
+	// (d0*d0 + d2*d2) + (d1*d1 + d3*d3) + d4*d4.
+	FMULD F5, F5
+	FMULD F6, F6
+	FMULD F7, F7
+	FMULD F8, F8
+	FMULD F9, F9
+	FADDD F7, F5
+	FADDD F8, F6
+	FADDD F6, F5
+	FADDD F9, F5
+
+	// Keep the distance if it is the first one or smaller than the best
+	// one. Unordered comparisons with NaN are not smaller.
+	CMP   $0, R3
+	BLT   take
+	FCMPD F16, F5
+	BMI   take
+	B     next
+
+take:
+	FMOVD F5, F16
+	MOVD  R4, R3
+
+next:
+	ADD $40, R0
+	ADD $1, R4
+	SUB $5, R1
+	B   loop
+
+done:
+	MOVD R3, ret+32(FP)
+	RET
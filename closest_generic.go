@@ -0,0 +1,7 @@
+//go:build (!amd64 && !arm64) || purego
+
+package dominantcolor
+
+func closestCentroid(centroids []float64, v *vector) int {
+	return closestCentroidGeneric(centroids, v)
+}
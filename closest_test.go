@@ -0,0 +1,64 @@
+package dominantcolor
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestClosestCentroid(t *testing.T) {
+	rnd := rand.New(rand.NewSource(0))
+	randomVector := func() (v vector) {
+		for i := range v {
+			v[i] = float64(rnd.Intn(256)) + rnd.Float64()
+		}
+		return
+	}
+	for k := 0; k < 10; k++ {
+		clusters := make(kMeanClusterGroup, k)
+		for i := range clusters {
+			clusters[i] = &kMeanCluster{centroid: randomVector()}
+		}
+		centroids := clusters.flatCentroids()
+		for n := 0; n < 1000; n++ {
+			v := randomVector()
+			got := closestCentroid(centroids, &v)
+			want := closestCentroidGeneric(centroids, &v)
+			if got != want {
+				t.Fatalf("closestCentroid = %d, want %d", got, want)
+			}
+			if k > 0 && clusters[got] != clusters.Closest(v) {
+				t.Fatalf("closestCentroid = %d does not match Closest", got)
+			}
+		}
+	}
+}
+
+func TestClosestCentroid_NaN(t *testing.T) {
+	for _, x := range []float64{math.Inf(1), math.NaN()} {
+		centroids := []float64{x, 0, 0, 0, 0, 0, x, 0, 0, 0}
+		v := vector{0, 0, 0, 0, 0}
+		if got := closestCentroid(centroids, &v); got != closestCentroidGeneric(centroids, &v) || got != 0 {
+			t.Errorf("closestCentroid = %d for distances of %v, want 0", got, x)
+		}
+	}
+}
+
+func BenchmarkClosestCentroid(b *testing.B) {
+	clusters := make(kMeanClusterGroup, 8)
+	for i := range clusters {
+		clusters[i] = &kMeanCluster{centroid: vector{float64(i * 32), 128, float64(255 - i*32)}}
+	}
+	centroids := clusters.flatCentroids()
+	v := vector{200, 100, 50}
+	b.Run("dispatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			closestCentroid(centroids, &v)
+		}
+	})
+	b.Run("generic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			closestCentroidGeneric(centroids, &v)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// Cluster describes a cluster of colors found in an image, for callers
+// that need more than the colors and weights returned by FindWeight.
+type Cluster struct {
+	// Color of the centroid of the cluster.
+	Centroid color.RGBA
+
+	// Fraction of the image covered by the cluster, as returned by
+	// FindWeight.
+	Weight float64
+
+	// Number of pixels of the working image closest to the centroid.
+	// Transparent and excluded pixels are not counted.
+	Count int
+
+	// Weighted mean of the squared distances of the pixels closest to the
+	// centroid to it in RGB space. The square root of the variance is the
+	// typical distance of the colors of the cluster to its centroid.
+	Variance float64
+}
+
+// FindClusters is like FindWeight but returns a description of each
+// cluster of colors.
+func FindClusters(img image.Image, nClusters int, opts ...Option) []Cluster {
+	if nClusters <= 0 {
+		nClusters = nClustersDefault
+	}
+	if ValidateInput(img, nClusters) != nil {
+		return []Cluster{}
+	}
+	clusters, points, totalWeight := findClusters(img, nClusters, newOptions(opts))
+	index := make(map[*kMeanCluster]int, len(clusters))
+	result := make([]Cluster, len(clusters))
+	for i, c := range clusterColors(clusters, totalWeight) {
+		index[clusters[i]] = i
+		result[i] = Cluster{Centroid: c.RGBA, Weight: c.Weight}
+	}
+	weights := make([]float64, len(clusters))
+	for _, p := range points {
+		if p.weight == 0 || len(clusters) == 0 {
+			continue
+		}
+		c := clusters.Closest(p.v)
+		i := index[c]
+		d0, d1, d2 := p.v[0]-c.centroid[0], p.v[1]-c.centroid[1], p.v[2]-c.centroid[2]
+		result[i].Count++
+		result[i].Variance += p.weight * (d0*d0 + d1*d1 + d2*d2)
+		weights[i] += p.weight
+	}
+	for i := range result {
+		if weights[i] > 0 {
+			result[i].Variance /= weights[i]
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package dominantcolor
+
+import "image/color"
+
+// Sample is a color observed with the given weight, such as the number of
+// pixels of that color.
+type Sample struct {
+	color.RGBA
+	Weight float64
+}
+
+// Clusterer exposes the individual steps of the KMean algorithm used by
+// FindWeight, so that callers can drive the loop themselves, for example
+// with custom stopping rules or by adjusting centroids between steps.
+//
+//	c := NewClusterer(samples, 4)
+//	for i := 0; i < 50 && !c.Step(); i++ {
+//	}
+//	centroids, weights := c.Centroids(), c.Weights()
+type Clusterer struct {
+	samples  []point
+	clusters kMeanClusterGroup
+	k        int
+}
+
+// NewClusterer returns a Clusterer of k clusters initialized with samples.
+// If k is less than or equal to 0, the value defaults to 4.
+func NewClusterer(samples []Sample, k int) *Clusterer {
+	if k <= 0 {
+		k = nClustersDefault
+	}
+	c := &Clusterer{k: k}
+	c.Init(samples)
+	return c
+}
+
+// Init replaces the samples and picks new starting centroids by randomly
+// sampling them with a fixed seed. Samples with the same color are merged
+// and samples with zero or negative weight are ignored. There may be fewer
+// than k centroids if the samples do not have enough distinct colors.
+func (c *Clusterer) Init(samples []Sample) {
+	points := make([]point, 0, len(samples))
+	for _, s := range samples {
+		if s.Weight <= 0 {
+			continue
+		}
+		points = append(points, point{
+			v:      vector{float64(s.R), float64(s.G), float64(s.B)},
+			weight: s.Weight,
+		})
+	}
+	c.samples = histogramPoints(points)
+	c.clusters = randomSeeds(nil, c.samples, len(c.samples), 1, c.k)
+}
+
+// SetCentroids replaces the centroids, and therefore the number of
+// clusters, with the given colors. Their weights are reset to zero until
+// the next step.
+func (c *Clusterer) SetCentroids(centroids []color.RGBA) {
+	c.clusters = make(kMeanClusterGroup, len(centroids))
+	for i, rgba := range centroids {
+		c.clusters[i] = new(kMeanCluster)
+		c.clusters[i].SetCentroid(vector{float64(rgba.R), float64(rgba.G), float64(rgba.B)})
+	}
+}
+
+// Step assigns each sample to the closest centroid and moves the centroids
+// to the mean color of their samples. It returns whether the centroids
+// have converged, in which case further steps do not change them.
+func (c *Clusterer) Step() bool {
+	if len(c.clusters) == 0 {
+		return true
+	}
+	return c.clusters.Step(c.samples)
+}
+
+// Centroids returns the current centroids. Their order is stable across
+// steps.
+func (c *Clusterer) Centroids() []color.RGBA {
+	centroids := make([]color.RGBA, len(c.clusters))
+	for i, k := range c.clusters {
+		r, g, b := k.Centroid()
+		centroids[i] = color.RGBA{R: r, G: g, B: b, A: 0xff}
+	}
+	return centroids
+}
+
+// Weights returns the total weight of the samples assigned to each
+// centroid in the last step, in the same order as Centroids. A centroid
+// that was assigned no samples keeps its previous weight.
+func (c *Clusterer) Weights() []float64 {
+	weights := make([]float64, len(c.clusters))
+	for i, k := range c.clusters {
+		weights[i] = k.weight
+	}
+	return weights
+}
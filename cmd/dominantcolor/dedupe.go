@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+// Number of colors of the palettes compared by dedupe.
+const signatureColors = 4
+
+// dedupe prints groups of images in a directory whose palettes are within
+// a color difference of each other, one group per line.
+func dedupe(args []string, w io.Writer) error {
+	fs := newFlagSet("dedupe")
+	threshold := fs.Float64("threshold", 5, "largest palette distance, in delta-E, of images in a group")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("dedupe takes a single directory")
+	}
+	paths, err := imageFiles(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	palettes := make([][]dominantcolor.Color, len(paths))
+	for i, path := range paths {
+		img, err := loadImage(path)
+		if err != nil {
+			return err
+		}
+		palettes[i] = dominantcolor.FindWeight(img, signatureColors)
+	}
+	for _, group := range groupSimilar(palettes, *threshold) {
+		if len(group) < 2 {
+			continue
+		}
+		names := make([]string, len(group))
+		for i, j := range group {
+			names[i] = paths[j]
+		}
+		fmt.Fprintln(w, strings.Join(names, "\t"))
+	}
+	return nil
+}
+
+// groupSimilar returns the indexes of palettes grouped so that each palette
+// is within threshold of another palette of its group. Groups are sorted by
+// their first index.
+func groupSimilar(palettes [][]dominantcolor.Color, threshold float64) [][]int {
+	// Union-find of the palettes.
+	parent := make([]int, len(palettes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range palettes {
+		for j := i + 1; j < len(palettes); j++ {
+			if dominantcolor.PaletteDistance(palettes[i], palettes[j]) <= threshold {
+				parent[root(j)] = root(i)
+			}
+		}
+	}
+	index := make(map[int]int)
+	var groups [][]int
+	for i := range palettes {
+		r := root(i)
+		g, ok := index[r]
+		if !ok {
+			g = len(groups)
+			index[r] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
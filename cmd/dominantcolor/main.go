@@ -0,0 +1,236 @@
+// Command dominantcolor prints the dominant colors of images and organizes
+// collections of images by color.
+//
+// Usage:
+//
+//	dominantcolor [-n colors] [-format text|jsonl|swatch] file|dir...
+//	dominantcolor dedupe [-threshold deltaE] dir
+//	dominantcolor search -color #RRGGBB [-tolerance deltaE] dir
+//	dominantcolor sort [-by hue|luminance] [-link outdir] [-collage out.png] dir
+//	dominantcolor stream [-n colors] [-format text|jsonl|swatch] < images
+//
+// The stream command reads images from the standard input, each preceded
+// by its length in bytes as a 4-byte big-endian unsigned integer, and
+// prints the colors of each image as soon as it is read.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+const usage = `usage: dominantcolor [-n colors] [-format text|jsonl|swatch] file|dir...
+       dominantcolor dedupe [-threshold deltaE] dir
+       dominantcolor search -color #RRGGBB [-tolerance deltaE] dir
+       dominantcolor sort [-by hue|luminance] [-link outdir] [-collage out.png] dir
+       dominantcolor stream [-n colors] [-format text|jsonl|swatch] < images
+`
+
+// Subcommands by name. Each is given its arguments and the output.
+var commands = map[string]func(args []string, w io.Writer) error{
+	"dedupe": dedupe,
+	"search": search,
+	"sort":   sortImages,
+	"stream": stream,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dominantcolor: ")
+	args := os.Args[1:]
+	run := find
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			run, args = cmd, args[1:]
+		}
+	}
+	if err := run(args, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newFlagSet returns a flag set for a subcommand printing the usage on
+// errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// find prints the dominant colors of each file, and of the images in each
+// directory.
+func find(args []string, w io.Writer) error {
+	fs := newFlagSet("dominantcolor")
+	n := fs.Int("n", 1, "number of colors to print, in order of dominance")
+	format := fs.String("format", "text", `output format: "text", "jsonl" for a JSON object per file, or "swatch" for colored swatches`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no files given")
+	}
+	write, err := newWriter(*format, w)
+	if err != nil {
+		return err
+	}
+	for _, arg := range fs.Args() {
+		paths := []string{arg}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			if paths, err = imageFiles(arg); err != nil {
+				return err
+			}
+		}
+		for _, path := range paths {
+			var colors []dominantcolor.Color
+			img, err := loadImage(path)
+			if err == nil {
+				colors = findColors(img, *n)
+			}
+			if err := write(path, colors, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findColors returns the n dominant colors of img with their weights. A
+// single color is picked as by Find, which skips colors unfit for display,
+// from the same clusters that give its weight. Images without colors, such
+// as fully transparent ones, have none.
+func findColors(img image.Image, n int) []dominantcolor.Color {
+	if n != 1 {
+		return dominantcolor.FindWeight(img, n)
+	}
+	_, candidates := dominantcolor.FindTrace(img)
+	for _, c := range candidates {
+		if c.Picked {
+			return []dominantcolor.Color{c.Color}
+		}
+	}
+	return []dominantcolor.Color{}
+}
+
+// newWriter returns a function writing the colors found in a file, or the
+// error reading it, in the given format.
+func newWriter(format string, w io.Writer) (func(path string, colors []dominantcolor.Color, err error) error, error) {
+	switch format {
+	case "text":
+		return writeText(w), nil
+	case "jsonl":
+		return writeJSONL(w), nil
+	case "swatch":
+		return writeSwatches(w), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// writeText returns a function writing the colors of each file as a line
+// of tab separated path and hex colors. Errors stop the command.
+func writeText(w io.Writer) func(string, []dominantcolor.Color, error) error {
+	return func(path string, colors []dominantcolor.Color, err error) error {
+		if err != nil {
+			return err
+		}
+		hexes := make([]string, len(colors))
+		for i, c := range colors {
+			hexes[i] = dominantcolor.Hex(c.RGBA)
+		}
+		_, err = fmt.Fprintf(w, "%s\t%s\n", path, strings.Join(hexes, " "))
+		return err
+	}
+}
+
+// writeSwatches returns a function writing the colors of each file as a
+// line of tab separated path and swatches, colored for terminals. Errors
+// stop the command.
+func writeSwatches(w io.Writer) func(string, []dominantcolor.Color, error) error {
+	return func(path string, colors []dominantcolor.Color, err error) error {
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\t%s\n", path, dominantcolor.Swatches(colors, dominantcolor.NoEmoji))
+		return err
+	}
+}
+
+// fileResult is the JSON object written for each file in the jsonl format.
+type fileResult struct {
+	Path   string      `json:"path"`
+	Colors []colorJSON `json:"colors,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type colorJSON struct {
+	Hex    string  `json:"hex"`
+	Weight float64 `json:"weight"`
+	// Sum of the weights of the color and the colors before it.
+	Cumulative float64 `json:"cumulative"`
+}
+
+// writeJSONL returns a function writing the colors of each file as a JSON
+// object on its own line. Files that cannot be read are reported in the
+// object, so that long scans are not interrupted.
+func writeJSONL(w io.Writer) func(string, []dominantcolor.Color, error) error {
+	enc := json.NewEncoder(w)
+	return func(path string, colors []dominantcolor.Color, err error) error {
+		r := fileResult{Path: path}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		cumulative := dominantcolor.CumulativeWeights(colors)
+		for i, c := range colors {
+			r.Colors = append(r.Colors, colorJSON{Hex: dominantcolor.Hex(c.RGBA), Weight: c.Weight, Cumulative: cumulative[i]})
+		}
+		return enc.Encode(r)
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// imageFiles returns the paths of the image files in dir and its
+// subdirectories, in lexical order.
+func imageFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".png", ".jpg", ".jpeg", ".gif":
+			if !d.IsDir() {
+				paths = append(paths, path)
+			}
+		}
+		return nil
+	})
+	return paths, err
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeImage writes a PNG image of size 32x32 filled with c to dir.
+func writeImage(t *testing.T, dir, name string, c color.Color) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	writeImage(t, dir, "b.png", color.RGBA{R: 30, G: 30, B: 200, A: 255})
+	writeImage(t, dir, "c.png", color.RGBA{R: 202, G: 31, B: 29, A: 255})
+	writeImage(t, dir, "d.png", color.RGBA{R: 30, G: 200, B: 30, A: 255})
+	var out bytes.Buffer
+	if err := dedupe([]string{dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "a.png") + "\t" + filepath.Join(dir, "c.png")
+	if got := strings.TrimSpace(out.String()); got != want {
+		t.Errorf("Unexpected groups: %q, want %q", got, want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{R: 30, G: 30, B: 200, A: 255})
+	writeImage(t, dir, "b.png", color.RGBA{R: 230, G: 96, B: 10, A: 255})
+	writeImage(t, dir, "c.png", color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	var out bytes.Buffer
+	if err := search([]string{"-color", "#E6600A", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var got []string
+	for _, line := range lines {
+		got = append(got, filepath.Base(strings.Split(line, "\t")[0]))
+	}
+	if want := "b.png c.png a.png"; strings.Join(got, " ") != want {
+		t.Errorf("Unexpected ranking: %q, want %s", lines, want)
+	}
+	if !strings.HasSuffix(lines[0], "\t100.0%\t0.0") {
+		t.Errorf("Unexpected best match: %q", lines[0])
+	}
+}
+
+func TestSortImages(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{R: 30, G: 30, B: 200, A: 255})
+	writeImage(t, dir, "b.png", color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	writeImage(t, dir, "c.png", color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	writeImage(t, dir, "d.png", color.RGBA{R: 30, G: 200, B: 30, A: 255})
+	links := filepath.Join(t.TempDir(), "sorted")
+	var out bytes.Buffer
+	if err := sortImages([]string{"-link", links, dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want := "0001-c.png 0002-d.png 0003-a.png 0004-b.png"; strings.Join(got, " ") != want {
+		t.Errorf("Unexpected links: %s, want %s", got, want)
+	}
+
+	out.Reset()
+	if err := sortImages([]string{"-by", "luminance", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		got = append(got, filepath.Base(strings.Split(line, "\t")[0]))
+	}
+	if want := "a.png c.png b.png d.png"; strings.Join(got, " ") != want {
+		t.Errorf("Unexpected order: %s, want %s", got, want)
+	}
+}
+
+func TestFind_JSONL(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	bad := filepath.Join(dir, "b.png")
+	if err := os.WriteFile(bad, []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := find([]string{"-format", "jsonl", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&out)
+	var results []fileResult
+	for dec.More() {
+		var r fileResult
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	want := []colorJSON{{Hex: "#C81E1E", Weight: 1, Cumulative: 1}}
+	if r := results[0]; r.Error != "" || len(r.Colors) != 1 || r.Colors[0] != want[0] {
+		t.Errorf("Unexpected result: %+v", r)
+	}
+	if r := results[1]; r.Path != bad || r.Error == "" || len(r.Colors) != 0 {
+		t.Errorf("Unexpected result for invalid image: %+v", r)
+	}
+}
+
+func TestFind_Transparent(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{})
+	var out bytes.Buffer
+	if err := find([]string{"-format", "jsonl", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var r fileResult
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Error != "" || len(r.Colors) != 0 {
+		t.Errorf("Unexpected result for transparent image: %+v", r)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var in bytes.Buffer
+	for _, c := range []color.RGBA{{R: 200, G: 30, B: 30, A: 255}, {R: 30, G: 30, B: 200, A: 255}} {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := binary.Write(&in, binary.BigEndian, uint32(buf.Len())); err != nil {
+			t.Fatal(err)
+		}
+		in.Write(buf.Bytes())
+	}
+	defer func(r io.Reader) { stdin = r }(stdin)
+	stdin = &in
+	var out bytes.Buffer
+	if err := stream(nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0\t#C81E1E\n1\t#1E1EC8\n"; out.String() != want {
+		t.Errorf("Unexpected output %q, want %q", out.String(), want)
+	}
+}
+
+func TestStream_Errors(t *testing.T) {
+	var in bytes.Buffer
+	writeFrame := func(data []byte) {
+		if err := binary.Write(&in, binary.BigEndian, uint32(len(data))); err != nil {
+			t.Fatal(err)
+		}
+		in.Write(data)
+	}
+	encode := func(size int) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 30, B: 30, A: 255}), image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	writeFrame([]byte("not an image"))
+	writeFrame(encode(100))
+	writeFrame(encode(8))
+	defer func(r io.Reader) { stdin = r }(stdin)
+	stdin = &in
+	var out bytes.Buffer
+	if err := stream([]string{"-max-pixels", "1000"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "0\terror: image: unknown format\n" +
+		"1\terror: dominantcolor: image size 100x100 exceeds limits\n" +
+		"2\t#C81E1E\n"
+	if out.String() != want {
+		t.Errorf("Unexpected output %q, want %q", out.String(), want)
+	}
+}
+
+func TestFind_Swatch(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	path := filepath.Join(dir, "a.png")
+	var out bytes.Buffer
+	if err := find([]string{"-format", "swatch", path}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := path + "\t\x1b[38;2;200;30;30m██\x1b[0m #C81E1E 100%\n"; out.String() != want {
+		t.Errorf("Unexpected output: %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+// match is an image ranked by search.
+type match struct {
+	path     string
+	coverage float64
+	distance float64
+}
+
+// search prints the images in a directory ranked by how much of them is
+// covered by colors similar to a query color, then by the distance of
+// their closest dominant color to the query.
+func search(args []string, w io.Writer) error {
+	fs := newFlagSet("search")
+	query := fs.String("color", "", "query color in #RRGGBB format")
+	tolerance := fs.Float64("tolerance", 20, "largest difference, in delta-E, of colors matching the query")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("search takes a single directory")
+	}
+	c, err := dominantcolor.ParseHex(*query)
+	if err != nil {
+		return err
+	}
+	paths, err := imageFiles(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	matches := make([]match, len(paths))
+	for i, path := range paths {
+		img, err := loadImage(path)
+		if err != nil {
+			return err
+		}
+		colors := dominantcolor.FindWeight(img, signatureColors)
+		m := match{path: path, distance: math.Inf(1)}
+		m.coverage = dominantcolor.Coverage(colors, c, *tolerance)
+		for _, cc := range colors {
+			m.distance = math.Min(m.distance, dominantcolor.DeltaE(cc.RGBA, c))
+		}
+		matches[i] = m
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].coverage != matches[j].coverage {
+			return matches[i].coverage > matches[j].coverage
+		}
+		return matches[i].distance < matches[j].distance
+	})
+	for _, m := range matches {
+		fmt.Fprintf(w, "%s\t%.1f%%\t%.1f\n", m.path, m.coverage*100, m.distance)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+// sortImages prints the images in a directory ordered by the hue or the
+// luminance of their dominant color. If a link directory is given, symbolic
+// links to the images, named after their rank, are created in it. If a
+// collage file is given, a contact sheet of the images ordered by hue is
+// written to it in PNG format.
+func sortImages(args []string, w io.Writer) error {
+	fs := newFlagSet("sort")
+	by := fs.String("by", "hue", `sort key: "hue" or "luminance"`)
+	link := fs.String("link", "", "directory in which to create ordered symbolic links to the images")
+	collage := fs.String("collage", "", "PNG file in which to write a contact sheet of the images ordered by hue")
+	columns := fs.Int("columns", 8, "number of columns of the contact sheet")
+	cellSize := fs.Int("cell", 128, "size in pixels of the images in the contact sheet")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("sort takes a single directory")
+	}
+	var less func(a, b color.RGBA) bool
+	switch *by {
+	case "hue":
+		less = dominantcolor.HueLess
+	case "luminance":
+		less = func(a, b color.RGBA) bool {
+			return dominantcolor.RelativeLuminance(a) < dominantcolor.RelativeLuminance(b)
+		}
+	default:
+		return fmt.Errorf("unknown sort key %q", *by)
+	}
+	paths, err := imageFiles(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	colors := make(map[string]color.RGBA, len(paths))
+	var images []image.Image
+	for _, path := range paths {
+		img, err := loadImage(path)
+		if err != nil {
+			return err
+		}
+		colors[path] = dominantcolor.Find(img)
+		if *collage != "" {
+			images = append(images, img)
+		}
+	}
+	if *collage != "" {
+		if err := writePNG(*collage, dominantcolor.Collage(images, *columns, *cellSize)); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return less(colors[paths[i]], colors[paths[j]]) })
+	if *link != "" {
+		if err := os.MkdirAll(*link, 0o755); err != nil {
+			return err
+		}
+	}
+	for i, path := range paths {
+		fmt.Fprintf(w, "%s\t%s\n", path, dominantcolor.Hex(colors[path]))
+		if *link == "" {
+			continue
+		}
+		target, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%04d-%s", i+1, filepath.Base(path))
+		if err := os.Symlink(target, filepath.Join(*link, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+// Input of the stream command.
+var stdin io.Reader = os.Stdin
+
+// stream reads images from the standard input and prints their dominant
+// colors as soon as each image is read, so that the command can serve
+// other programs as a long-lived subprocess. Each image is preceded by its
+// length in bytes as a 4-byte big-endian unsigned integer. Results are
+// written in the formats of the find mode, with the index of the image in
+// the stream, starting from 0, in place of the path. Images that cannot be
+// decoded are reported on their line, as "error:" followed by the reason in
+// the text and swatch formats, and do not end the stream.
+func stream(args []string, w io.Writer) error {
+	fs := newFlagSet("stream")
+	n := fs.Int("n", 1, "number of colors to print, in order of dominance")
+	format := fs.String("format", "text", `output format: "text", "jsonl" for a JSON object per image, or "swatch" for colored swatches`)
+	maxSize := fs.Uint("max-size", 64<<20, "largest accepted image length in bytes")
+	maxPixels := fs.Int64("max-pixels", 64<<20, "largest accepted image width times height, checked before decoding")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("stream takes no arguments")
+	}
+	write, err := newWriter(*format, w)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(stdin)
+	var buf []byte
+	for i := 0; ; i++ {
+		var length uint32
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if uint(length) > *maxSize {
+			return fmt.Errorf("image %d: length %d exceeds %d bytes", i, length, *maxSize)
+		}
+		if cap(buf) < int(length) {
+			buf = make([]byte, length)
+		}
+		buf = buf[:length]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return fmt.Errorf("image %d: %w", i, err)
+		}
+		var colors []dominantcolor.Color
+		img, err := decodeLimited(buf, *maxPixels)
+		if err == nil {
+			colors = findColors(img, *n)
+		} else if *format != "jsonl" {
+			// The text and swatch writers stop on errors, which would end
+			// the stream for every later image.
+			if _, err := fmt.Fprintf(w, "%d\terror: %v\n", i, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := write(strconv.Itoa(i), colors, err); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeLimited decodes the image in data after checking that the size in
+// its header does not exceed maxPixels, so that decompression bombs are
+// rejected before their pixels are allocated.
+func decodeLimited(data []byte, maxPixels int64) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width < 0 || config.Height < 0 || int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, &dominantcolor.ImageSizeError{Width: config.Width, Height: config.Height}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
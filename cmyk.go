@@ -0,0 +1,90 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// CMYKColor is a color in CMYK with the fraction of the image it covers.
+type CMYKColor struct {
+	color.CMYK
+	Weight float64
+}
+
+// FindCMYK is like FindWeight but returns the colors in CMYK for prepress
+// tools. If img is an *image.CMYK, each color is the mean of the original
+// CMYK values of the pixels closest to it, so that the ink composition of
+// the source, such as a rich black instead of pure K, is preserved instead
+// of being recomputed from RGB. Otherwise colors are converted with
+// color.CMYKModel.
+func FindCMYK(img image.Image, nClusters int, opts ...Option) []CMYKColor {
+	colors := FindWeight(img, nClusters, opts...)
+	cmyk := make([]CMYKColor, len(colors))
+	for i, c := range colors {
+		cmyk[i] = CMYKColor{CMYK: color.CMYKModel.Convert(c.RGBA).(color.CMYK), Weight: c.Weight}
+	}
+	if src, ok := img.(*image.CMYK); ok && len(colors) > 0 {
+		sourceInks(src, colors, cmyk, newOptions(opts))
+	}
+	return cmyk
+}
+
+// sourceInks sets each of cmyk to the mean CMYK value of the pixels of src
+// closest to the corresponding color. Pixels are sampled with a stride so
+// that about as many pixels as in the working image are visited.
+func sourceInks(src *image.CMYK, colors []Color, cmyk []CMYKColor, o *options) {
+	mask := newWeightMask(src, o)
+	bounds := src.Bounds()
+	step := bounds.Dx()
+	if bounds.Dy() > step {
+		step = bounds.Dy()
+	}
+	step /= o.workingSize()
+	if step < 1 {
+		step = 1
+	}
+	sums := make([][4]float64, len(colors))
+	counts := make([]float64, len(colors))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			w := mask.weight(x, y)
+			if w == 0 {
+				continue
+			}
+			c := src.CMYKAt(x, y)
+			r, g, b := color.CMYKToRGB(c.C, c.M, c.Y, c.K)
+			i := closestColor(colors, r, g, b)
+			sums[i][0] += float64(c.C) * w
+			sums[i][1] += float64(c.M) * w
+			sums[i][2] += float64(c.Y) * w
+			sums[i][3] += float64(c.K) * w
+			counts[i] += w
+		}
+	}
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		s := sums[i]
+		cmyk[i].CMYK = color.CMYK{
+			C: clampChannel(s[0] / n),
+			M: clampChannel(s[1] / n),
+			Y: clampChannel(s[2] / n),
+			K: clampChannel(s[3] / n),
+		}
+	}
+}
+
+// closestColor returns the index of the color closest to (r, g, b) in RGB
+// space.
+func closestColor(colors []Color, r, g, b uint8) int {
+	closest, distanceToClosest := 0, -1
+	for i, c := range colors {
+		dr, dg, db := int(c.R)-int(r), int(c.G)-int(g), int(c.B)-int(b)
+		d := dr*dr + dg*dg + db*db
+		if distanceToClosest < 0 || d < distanceToClosest {
+			closest, distanceToClosest = i, d
+		}
+	}
+	return closest
+}
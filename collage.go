@@ -0,0 +1,73 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// Colors with a lower HSLuv saturation are ordered as grays by HueLess.
+const graySaturation = 10
+
+// HueLess reports whether a comes before b when ordering colors around the
+// HSLuv hue wheel, starting from red. Grays follow all other colors, from
+// dark to light.
+func HueLess(a, b color.RGBA) bool {
+	ha, hb := ToHSLuv(a), ToHSLuv(b)
+	grayA, grayB := ha.S < graySaturation, hb.S < graySaturation
+	switch {
+	case grayA != grayB:
+		return grayB
+	case grayA:
+		return ha.L < hb.L
+	}
+	return ha.H < hb.H
+}
+
+// Collage returns a contact sheet of images ordered by the hue of their
+// dominant color, as ordered by HueLess, so that they form a color
+// gradient. Images are laid out in rows of the given number of columns,
+// each cropped to a square at its center and scaled to cellSize pixels.
+// Images rejected by ValidateInput, such as nil or empty ones, are skipped.
+func Collage(images []image.Image, columns, cellSize int, opts ...Option) *image.RGBA {
+	if columns < 1 {
+		columns = 1
+	}
+	valid := make([]image.Image, 0, len(images))
+	for _, img := range images {
+		if ValidateInput(img, 0) == nil {
+			valid = append(valid, img)
+		}
+	}
+	images = valid
+	rows := (len(images) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*cellSize, rows*cellSize))
+	colors := make([]color.RGBA, len(images))
+	order := make([]int, len(images))
+	for i, img := range images {
+		colors[i] = Find(img, opts...)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return HueLess(colors[order[i]], colors[order[j]]) })
+	for i, j := range order {
+		x, y := i%columns*cellSize, i/columns*cellSize
+		cell := image.Rect(x, y, x+cellSize, y+cellSize)
+		draw.ApproxBiLinear.Scale(sheet, cell, images[j], squareCrop(images[j].Bounds()), draw.Src, nil)
+	}
+	return sheet
+}
+
+// squareCrop returns the largest square centered in r.
+func squareCrop(r image.Rectangle) image.Rectangle {
+	dx, dy := r.Dx(), r.Dy()
+	if dx > dy {
+		r.Min.X += (dx - dy) / 2
+		r.Max.X = r.Min.X + dy
+	} else {
+		r.Min.Y += (dy - dx) / 2
+		r.Max.Y = r.Min.Y + dx
+	}
+	return r
+}
@@ -0,0 +1,144 @@
+package dominantcolor
+
+import (
+	"image/color"
+	"math"
+)
+
+// lab is a color in CIE L*a*b* space under the D65 illuminant.
+type lab struct {
+	L, A, B float64
+}
+
+// D65 reference white.
+const (
+	whiteX = 0.95047
+	whiteY = 1.00000
+	whiteZ = 1.08883
+)
+
+func rgbToLab(r, g, b uint8) lab {
+	lr, lg, lb := linearize(r), linearize(g), linearize(b)
+	x := (0.4124564*lr + 0.3575761*lg + 0.1804375*lb) / whiteX
+	y := (0.2126729*lr + 0.7151522*lg + 0.0721750*lb) / whiteY
+	z := (0.0193339*lr + 0.1191920*lg + 0.9503041*lb) / whiteZ
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+func (c lab) RGBA() color.RGBA {
+	lr, lg, lb := c.linear()
+	return color.RGBA{R: delinearize(lr), G: delinearize(lg), B: delinearize(lb), A: 0xff}
+}
+
+// linear returns the linear sRGB channels of c, which are outside of
+// [0, 1] if c is out of the sRGB gamut.
+func (c lab) linear() (r, g, b float64) {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+	x := labFInv(fx) * whiteX
+	y := labFInv(fy) * whiteY
+	z := labFInv(fz) * whiteZ
+	r = 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g = -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b = 0.0556434*x - 0.2040259*y + 1.0572252*z
+	return r, g, b
+}
+
+// deltaE returns the CIE76 color difference between a and b.
+func deltaE(a, b lab) float64 {
+	dl, da, db := a.L-b.L, a.A-b.A, a.B-b.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29
+}
+
+func labFInv(t float64) float64 {
+	const delta = 6.0 / 29
+	if t > delta {
+		return t * t * t
+	}
+	return 3 * delta * delta * (t - 4.0/29)
+}
+
+// linearize converts an sRGB channel value to linear light in [0, 1].
+func linearize(c uint8) float64 {
+	v := float64(c) / 0xff
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// delinearize converts linear light to an sRGB channel value, clamping
+// out of gamut values.
+func delinearize(v float64) uint8 {
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return clampChannel(v * 0xff)
+}
+
+func clampChannel(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 0xff:
+		return 0xff
+	}
+	return uint8(math.Round(v))
+}
+
+// hsl returns the hue in degrees, saturation and lightness of c.
+func hsl(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/0xff, float64(c.G)/0xff, float64(c.B)/0xff
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	d := max - min
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// lch is a color in CIE LCh(ab) space, the polar form of CIE L*a*b*. The
+// hue is in degrees.
+type lch struct {
+	L, C, H float64
+}
+
+func (c lab) LCh() lch {
+	h := math.Atan2(c.B, c.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return lch{L: c.L, C: math.Hypot(c.A, c.B), H: h}
+}
+
+func (c lch) Lab() lab {
+	h := c.H * math.Pi / 180
+	return lab{L: c.L, A: c.C * math.Cos(h), B: c.C * math.Sin(h)}
+}
@@ -0,0 +1,67 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Number of standard errors of the 95% confidence interval of a normal
+// distribution.
+const z95 = 1.96
+
+// Confidence is the dominant color of an image, as returned by Find, with
+// measures of how well it represents its cluster of colors.
+type Confidence struct {
+	color.RGBA
+
+	// Root mean square color difference, in CIE76 delta-E, between the
+	// pixels of the cluster and the color. A crisp brand color has a
+	// spread close to 0, while the average of a wide range of browns has
+	// a much larger one.
+	Spread float64
+
+	// Radius, in delta-E, of the 95% confidence interval of the color as
+	// the mean of the pixels of the cluster, from its spread and the
+	// number of pixels of the working image in the cluster. Clusters of
+	// few pixels have large radii.
+	Radius float64
+
+	// Number of pixels of the working image in the cluster.
+	Pixels float64
+}
+
+// FindConfidence is like Find but also reports the spread of the colors of
+// the cluster of the dominant color and the uncertainty of its mean. It
+// returns a zero Confidence for invalid images.
+func FindConfidence(img image.Image, opts ...Option) Confidence {
+	if ValidateInput(img, nClustersDefault) != nil {
+		return Confidence{}
+	}
+	o := newOptions(opts)
+	clusters, points, totalWeight := findClusters(img, nClustersDefault, o)
+	c := Confidence{RGBA: o.pick(clusterColors(clusters, totalWeight))}
+	i := -1
+	for j, cluster := range clusters {
+		if r, g, b := cluster.Centroid(); c.RGBA == (color.RGBA{R: r, G: g, B: b, A: 0xff}) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return c
+	}
+	center := rgbToLab(c.R, c.G, c.B)
+	var sum float64
+	for _, m := range clusterMembers(clusters, points)[i] {
+		cm := m.v.RGBA()
+		d := deltaE(rgbToLab(cm.R, cm.G, cm.B), center)
+		sum += m.weight * d * d
+		c.Pixels += m.weight
+	}
+	if c.Pixels > 0 {
+		c.Spread = math.Sqrt(sum / c.Pixels)
+		c.Radius = z95 * c.Spread / math.Sqrt(c.Pixels)
+	}
+	return c
+}
@@ -0,0 +1,241 @@
+package dominantcolor
+
+import (
+	"fmt"
+	"image"
+)
+
+// Config is a plain representation of the options that can be loaded from
+// the configuration files of a service, such as JSON or YAML. Zero values
+// keep the defaults. Colors are in "#RRGGBB" format.
+type Config struct {
+	// Preset applied before the other options, "screenshots" for
+	// ForScreenshots, "photos" for ForPhotos, "logos" for ForLogos or
+	// "products" for ForProducts.
+	Preset string `json:"preset,omitempty" yaml:"preset,omitempty"`
+
+	Algorithm  Algorithm  `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	ColorSpace ColorSpace `json:"color_space,omitempty" yaml:"color_space,omitempty"`
+	Seeding    Seeding    `json:"seeding,omitempty" yaml:"seeding,omitempty"`
+
+	SpatialWeight float64 `json:"spatial_weight,omitempty" yaml:"spatial_weight,omitempty"`
+	Superpixels   int     `json:"superpixels,omitempty" yaml:"superpixels,omitempty"`
+	RegionWeight  bool    `json:"region_weight,omitempty" yaml:"region_weight,omitempty"`
+
+	// EdgeWeight is a pointer because zero ignores edges entirely.
+	EdgeWeight *float64 `json:"edge_weight,omitempty" yaml:"edge_weight,omitempty"`
+
+	QuantizeBits    int     `json:"quantize_bits,omitempty" yaml:"quantize_bits,omitempty"`
+	MaxMemory       int64   `json:"max_memory,omitempty" yaml:"max_memory,omitempty"`
+	AlphaThreshold  uint8   `json:"alpha_threshold,omitempty" yaml:"alpha_threshold,omitempty"`
+	Blur            int     `json:"blur,omitempty" yaml:"blur,omitempty"`
+	SampleSize      int     `json:"sample_size,omitempty" yaml:"sample_size,omitempty"`
+	Trim            float64 `json:"trim,omitempty" yaml:"trim,omitempty"`
+	WeightTolerance float64 `json:"weight_tolerance,omitempty" yaml:"weight_tolerance,omitempty"`
+	RasterSize      int     `json:"raster_size,omitempty" yaml:"raster_size,omitempty"`
+	NoResize        bool    `json:"no_resize,omitempty" yaml:"no_resize,omitempty"`
+
+	IncludeRegions []image.Rectangle `json:"include_regions,omitempty" yaml:"include_regions,omitempty"`
+	ExcludeRegions []image.Rectangle `json:"exclude_regions,omitempty" yaml:"exclude_regions,omitempty"`
+
+	// DiffTolerance is a pointer because zero counts any change.
+	DiffTolerance     *float64 `json:"diff_tolerance,omitempty" yaml:"diff_tolerance,omitempty"`
+	FrameDifferencing bool     `json:"frame_differencing,omitempty" yaml:"frame_differencing,omitempty"`
+	ResolutionStable  bool     `json:"resolution_stable,omitempty" yaml:"resolution_stable,omitempty"`
+	Salience          bool     `json:"salience,omitempty" yaml:"salience,omitempty"`
+	PresentColors     bool     `json:"present_colors,omitempty" yaml:"present_colors,omitempty"`
+	EXIFThumbnail     bool     `json:"exif_thumbnail,omitempty" yaml:"exif_thumbnail,omitempty"`
+
+	// SaturationBounds are the minimum and maximum saturations given to
+	// WithSaturationBounds.
+	SaturationBounds *[2]float64 `json:"saturation_bounds,omitempty" yaml:"saturation_bounds,omitempty"`
+
+	PinnedColors []string `json:"pinned_colors,omitempty" yaml:"pinned_colors,omitempty"`
+	IgnoreColors []string `json:"ignore_colors,omitempty" yaml:"ignore_colors,omitempty"`
+	AvoidColors  []string `json:"avoid_colors,omitempty" yaml:"avoid_colors,omitempty"`
+	AvoidDeltaE  float64  `json:"avoid_delta_e,omitempty" yaml:"avoid_delta_e,omitempty"`
+
+	// ExcludeHueRanges are pairs of hues in degrees as given to
+	// WithExcludeHueRange.
+	ExcludeHueRanges [][2]float64 `json:"exclude_hue_ranges,omitempty" yaml:"exclude_hue_ranges,omitempty"`
+}
+
+// Options returns the options configured by c. It returns an error if a
+// preset or color is invalid.
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+	switch c.Preset {
+	case "":
+	case "screenshots":
+		opts = append(opts, ForScreenshots())
+	case "photos":
+		opts = append(opts, ForPhotos())
+	case "logos":
+		opts = append(opts, ForLogos())
+	case "products":
+		opts = append(opts, ForProducts())
+	default:
+		return nil, fmt.Errorf("dominantcolor: unknown preset %q", c.Preset)
+	}
+	pinned, err := parseHexes(c.PinnedColors)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := parseHexes(c.IgnoreColors)
+	if err != nil {
+		return nil, err
+	}
+	avoid, err := parseHexes(c.AvoidColors)
+	if err != nil {
+		return nil, err
+	}
+	if c.Algorithm != KMean {
+		opts = append(opts, WithAlgorithm(c.Algorithm))
+	}
+	if c.ColorSpace != SpaceRGB {
+		opts = append(opts, WithColorSpace(c.ColorSpace))
+	}
+	if c.Seeding != RandomSeeding {
+		opts = append(opts, WithSeeding(c.Seeding))
+	}
+	if c.SpatialWeight != 0 {
+		opts = append(opts, WithSpatialWeight(c.SpatialWeight))
+	}
+	if c.Superpixels != 0 {
+		opts = append(opts, WithSuperpixels(c.Superpixels))
+	}
+	if c.RegionWeight {
+		opts = append(opts, WithRegionWeight())
+	}
+	if c.EdgeWeight != nil {
+		opts = append(opts, WithEdgeWeight(*c.EdgeWeight))
+	}
+	if c.QuantizeBits != 0 {
+		opts = append(opts, WithQuantizeBits(c.QuantizeBits))
+	}
+	if c.MaxMemory != 0 {
+		opts = append(opts, WithMaxMemory(c.MaxMemory))
+	}
+	if c.AlphaThreshold != 0 {
+		opts = append(opts, WithAlphaThreshold(c.AlphaThreshold))
+	}
+	if c.Blur != 0 {
+		opts = append(opts, WithBlur(c.Blur))
+	}
+	if c.SampleSize != 0 {
+		opts = append(opts, WithSampleSize(c.SampleSize))
+	}
+	if c.Trim != 0 {
+		opts = append(opts, WithTrim(c.Trim))
+	}
+	if c.WeightTolerance != 0 {
+		opts = append(opts, WithWeightTolerance(c.WeightTolerance))
+	}
+	if c.NoResize {
+		opts = append(opts, WithoutResize())
+	}
+	if c.RasterSize != 0 {
+		opts = append(opts, WithRasterSize(c.RasterSize))
+	}
+	if len(c.IncludeRegions) > 0 {
+		opts = append(opts, WithIncludeRegions(c.IncludeRegions...))
+	}
+	if len(c.ExcludeRegions) > 0 {
+		opts = append(opts, WithExcludeRegions(c.ExcludeRegions...))
+	}
+	if c.DiffTolerance != nil {
+		opts = append(opts, WithDiffTolerance(*c.DiffTolerance))
+	}
+	if c.FrameDifferencing {
+		opts = append(opts, WithFrameDifferencing())
+	}
+	if c.ResolutionStable {
+		opts = append(opts, WithResolutionStable())
+	}
+	if c.Salience {
+		opts = append(opts, WithSalience())
+	}
+	if c.PresentColors {
+		opts = append(opts, WithPresentColors())
+	}
+	if c.EXIFThumbnail {
+		opts = append(opts, WithEXIFThumbnail())
+	}
+	if b := c.SaturationBounds; b != nil {
+		opts = append(opts, WithSaturationBounds(b[0], b[1]))
+	}
+	if len(pinned) > 0 {
+		opts = append(opts, WithPinnedColors(pinned...))
+	}
+	if len(ignore) > 0 {
+		opts = append(opts, WithIgnoreColors(ignore...))
+	}
+	if len(avoid) > 0 {
+		opts = append(opts, WithAvoidColors(avoid, c.AvoidDeltaE))
+	}
+	for _, r := range c.ExcludeHueRanges {
+		opts = append(opts, WithExcludeHueRange(r[0], r[1]))
+	}
+	return opts, nil
+}
+
+// Names of the enumerations in configuration files.
+var (
+	algorithmNames  = []string{KMean: "kmean", HueSectors: "hue-sectors", KMedoids: "k-medoids"}
+	colorSpaceNames = []string{SpaceRGB: "rgb", SpaceOKLab: "oklab", SpaceYCbCr: "ycbcr", SpaceHSV: "hsv", SpaceLab: "lab"}
+	seedingNames    = []string{RandomSeeding: "random", StratifiedSeeding: "stratified", KMeansPlusPlus: "kmeans++"}
+)
+
+func (a Algorithm) String() string { return enumName(algorithmNames, int(a)) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Algorithm) MarshalText() ([]byte, error) { return marshalEnum(algorithmNames, int(a)) }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	return unmarshalEnum(algorithmNames, text, "algorithm", (*int)(a))
+}
+
+func (s ColorSpace) String() string { return enumName(colorSpaceNames, int(s)) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ColorSpace) MarshalText() ([]byte, error) { return marshalEnum(colorSpaceNames, int(s)) }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *ColorSpace) UnmarshalText(text []byte) error {
+	return unmarshalEnum(colorSpaceNames, text, "color space", (*int)(s))
+}
+
+func (s Seeding) String() string { return enumName(seedingNames, int(s)) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Seeding) MarshalText() ([]byte, error) { return marshalEnum(seedingNames, int(s)) }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Seeding) UnmarshalText(text []byte) error {
+	return unmarshalEnum(seedingNames, text, "seeding", (*int)(s))
+}
+
+func enumName(names []string, i int) string {
+	if i < 0 || i >= len(names) {
+		return fmt.Sprintf("%d", i)
+	}
+	return names[i]
+}
+
+func marshalEnum(names []string, i int) ([]byte, error) {
+	if i < 0 || i >= len(names) {
+		return nil, fmt.Errorf("dominantcolor: invalid value %d", i)
+	}
+	return []byte(names[i]), nil
+}
+
+func unmarshalEnum(names []string, text []byte, kind string, i *int) error {
+	for v, name := range names {
+		if string(text) == name {
+			*i = v
+			return nil
+		}
+	}
+	return fmt.Errorf("dominantcolor: unknown %s %q", kind, text)
+}
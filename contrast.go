@@ -0,0 +1,35 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// RelativeLuminance returns the relative luminance of c as defined by
+// WCAG 2, from 0 for black to 1 for white.
+func RelativeLuminance(c color.RGBA) float64 {
+	return 0.2126*linearize(c.R) + 0.7152*linearize(c.G) + 0.0722*linearize(c.B)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio between a and b, from 1
+// for identical colors to 21 for black and white. WCAG requires a ratio of
+// at least 4.5 for normal text and 3 for large text.
+func ContrastRatio(a, b color.RGBA) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// FindContrasting returns the heaviest dominant color of img whose contrast
+// ratio against bg is at least minRatio, such as a badge color drawn over a
+// known surface. It returns false if no color has enough contrast.
+func FindContrasting(img image.Image, bg color.RGBA, minRatio float64, opts ...Option) (color.RGBA, bool) {
+	for _, c := range FindWeight(img, nClustersDefault, opts...) {
+		if ContrastRatio(c.RGBA, bg) >= minRatio {
+			return c.RGBA, true
+		}
+	}
+	return color.RGBA{}, false
+}
@@ -0,0 +1,78 @@
+package dominantcolor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// DecodeLimits bounds the size of images accepted by DecodeAndFind. Zero
+// fields are not checked.
+type DecodeLimits struct {
+	MaxWidth, MaxHeight int
+
+	// Maximum number of pixels, width times height.
+	MaxPixels int64
+}
+
+// ImageSizeError is returned by DecodeAndFind when the size of an image
+// exceeds the limits.
+type ImageSizeError struct {
+	Width, Height int
+}
+
+func (e *ImageSizeError) Error() string {
+	return fmt.Sprintf("dominantcolor: image size %dx%d exceeds limits", e.Width, e.Height)
+}
+
+// DecodeAndFind decodes an image from r and returns its dominant color. The
+// dimensions of the image are read from its header and checked against
+// limits before it is decoded, to protect services analyzing untrusted
+// uploads from decompression bombs. Decoders of image formats must be
+// registered, as for image.Decode.
+func DecodeAndFind(r io.Reader, limits DecodeLimits, opts ...Option) (color.RGBA, error) {
+	// Keep the header read by DecodeConfig to decode the image after it.
+	var header bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("dominantcolor: decode config: %w", err)
+	}
+	if err := limits.check(config.Width, config.Height); err != nil {
+		return color.RGBA{}, err
+	}
+	o := newOptions(opts)
+	if o.exifThumbnail && format == "jpeg" && len(o.includeRegions) == 0 && len(o.excludeRegions) == 0 && o.masker == nil {
+		end := o.span(PhaseDecode)
+		thumb, _, err := image.Decode(bytes.NewReader(exifThumbnail(header.Bytes())))
+		end()
+		if err == nil && ValidateInput(thumb, 0) == nil {
+			return Find(thumbnailContent(thumb, config.Width, config.Height), opts...), nil
+		}
+	}
+	end := o.span(PhaseDecode)
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	end()
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("dominantcolor: decode: %w", err)
+	}
+	// The header may lie about the size of the image.
+	if err := limits.check(img.Bounds().Dx(), img.Bounds().Dy()); err != nil {
+		return color.RGBA{}, err
+	}
+	if err := ValidateInput(img, 0); err != nil {
+		return color.RGBA{}, err
+	}
+	return Find(img, opts...), nil
+}
+
+func (l DecodeLimits) check(width, height int) error {
+	if width < 0 || height < 0 ||
+		l.MaxWidth > 0 && width > l.MaxWidth ||
+		l.MaxHeight > 0 && height > l.MaxHeight ||
+		l.MaxPixels > 0 && int64(width)*int64(height) > l.MaxPixels {
+		return &ImageSizeError{Width: width, Height: height}
+	}
+	return nil
+}
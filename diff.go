@@ -0,0 +1,54 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// Default color difference in CIE76 delta-E above which a pixel is
+// considered changed.
+const diffToleranceDefault = 10
+
+// FindDiff is like FindWeight but only analyzes the pixels of img that
+// differ from the corresponding pixels of reference by more than the
+// tolerance set with WithDiffTolerance, such as a product photographed in
+// front of a known backdrop. The reference is scaled to the bounds of img if
+// they differ. Any Masker set with WithMasker is replaced.
+func FindDiff(img, reference image.Image, nClusters int, opts ...Option) []Color {
+	if isNil(reference) || reference.Bounds().Empty() {
+		return []Color{}
+	}
+	m := &diffMasker{reference: reference, tolerance: newOptions(opts).diffTolerance}
+	return FindWeight(img, nClusters, append(opts[:len(opts):len(opts)], WithMasker(m))...)
+}
+
+// diffMasker selects the pixels that differ from a reference image.
+type diffMasker struct {
+	reference image.Image
+	tolerance float64
+}
+
+// Mask returns a mask with the bounds of img, comparing each pixel with the
+// pixel of the reference at the same relative position, so that only the
+// reference is scaled.
+func (m *diffMasker) Mask(img image.Image) *image.Alpha {
+	bounds := img.Bounds()
+	refBounds := m.reference.Bounds()
+	mask := image.NewAlpha(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			rp := mapPoint(image.Pt(x, y), bounds, refBounds)
+			a := labAt(img, x, y)
+			b := labAt(m.reference, rp.X, rp.Y)
+			if deltaE(a, b) > m.tolerance {
+				mask.SetAlpha(x, y, color.Alpha{A: 0xff})
+			}
+		}
+	}
+	return mask
+}
+
+func labAt(img image.Image, x, y int) lab {
+	r, g, b, _ := rgbaAt(img, x, y)
+	return rgbToLab(uint8(r/0x101), uint8(g/0x101), uint8(b/0x101))
+}
@@ -0,0 +1,182 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Largest number of colors of a cluster considered as its medoid.
+const maxMedoidCandidates = 64
+
+// Distribution describes the spread of the colors that make up a dominant
+// color. Means of wide clusters are often muddy colors that do not occur
+// in the image, while their medians and medoids do not suffer from this.
+type Distribution struct {
+	// Mean color of the cluster and its weight, as returned by FindWeight.
+	Color
+
+	// Weighted 25th, 50th and 75th percentiles of each channel of the
+	// colors of the cluster. Since channels are computed separately, these
+	// colors may not occur in the image either.
+	P25, P50, P75 color.RGBA
+
+	// Color of the cluster with the smallest mean distance to the other
+	// colors of the cluster in RGB space. For clusters of many distinct
+	// colors, only the heaviest ones are considered.
+	Medoid color.RGBA
+
+	// Color of the cluster with the highest chroma in CIE LCh. Means of
+	// photographic clusters trend to gray, while this is the most vivid
+	// version of the color that occurs in the image.
+	Vivid color.RGBA
+}
+
+// FindDistributions is like FindWeight but also describes the distribution
+// of the colors of each cluster. Colors are taken from the working image
+// after blurring and superpixel segmentation, if enabled.
+func FindDistributions(img image.Image, nClusters int, opts ...Option) []Distribution {
+	if nClusters <= 0 {
+		nClusters = nClustersDefault
+	}
+	if ValidateInput(img, nClusters) != nil {
+		return []Distribution{}
+	}
+	clusters, points, totalWeight := findClusters(img, nClusters, newOptions(opts))
+	colors := clusterColors(clusters, totalWeight)
+	members := clusterMembers(clusters, points)
+	distributions := make([]Distribution, len(colors))
+	for i, c := range colors {
+		d := Distribution{Color: c, P25: c.RGBA, P50: c.RGBA, P75: c.RGBA, Medoid: c.RGBA, Vivid: c.RGBA}
+		if len(members[i]) > 0 {
+			d.P25 = percentile(members[i], 0.25)
+			d.P50 = percentile(members[i], 0.5)
+			d.P75 = percentile(members[i], 0.75)
+			d.Medoid = medoid(members[i])
+			d.Vivid = mostChromatic(members[i])
+		}
+		distributions[i] = d
+	}
+	return distributions
+}
+
+// clusterMembers returns the distinct colors of the points closest to each
+// cluster with their total weight, in the order of first occurrence.
+// Spatial features of the returned points are zero.
+func clusterMembers(clusters kMeanClusterGroup, points []point) [][]point {
+	index := make(map[*kMeanCluster]int, len(clusters))
+	for i, c := range clusters {
+		index[c] = i
+	}
+	members := make([][]point, len(clusters))
+	// With spatial features, points of the same color may be closest to
+	// different clusters, so colors are indexed per cluster.
+	type member struct {
+		cluster int
+		v       vector
+	}
+	colorIndex := make(map[member]int)
+	for _, p := range points {
+		if p.weight == 0 || len(clusters) == 0 {
+			continue
+		}
+		i := index[clusters.Closest(p.v)]
+		v := vector{p.v[0], p.v[1], p.v[2]}
+		if j, ok := colorIndex[member{i, v}]; ok {
+			members[i][j].weight += p.weight
+			continue
+		}
+		colorIndex[member{i, v}] = len(members[i])
+		members[i] = append(members[i], point{v: v, weight: p.weight})
+	}
+	return members
+}
+
+// snapToMembers replaces the color of each cluster that is not pinned by
+// the closest color of the points closest to it.
+func snapToMembers(clusters kMeanClusterGroup, points []point) {
+	for i, members := range clusterMembers(clusters, points) {
+		c := clusters[i]
+		if c.pinned || len(members) == 0 {
+			continue
+		}
+		closest := members[0].v
+		distanceToClosest := math.Inf(1)
+		for _, m := range members {
+			d0, d1, d2 := m.v[0]-c.centroid[0], m.v[1]-c.centroid[1], m.v[2]-c.centroid[2]
+			if d := d0*d0 + d1*d1 + d2*d2; d < distanceToClosest {
+				closest, distanceToClosest = m.v, d
+			}
+		}
+		copy(c.centroid[:3], closest[:3])
+	}
+}
+
+// percentile returns the weighted q-quantile of each channel of colors.
+func percentile(colors []point, q float64) color.RGBA {
+	var total float64
+	for _, p := range colors {
+		total += p.weight
+	}
+	sorted := make([]point, len(colors))
+	var channels [3]float64
+	for c := range channels {
+		copy(sorted, colors)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].v[c] < sorted[j].v[c] })
+		var sum float64
+		for _, p := range sorted {
+			sum += p.weight
+			channels[c] = p.v[c]
+			if sum >= q*total {
+				break
+			}
+		}
+	}
+	return vector{channels[0], channels[1], channels[2]}.RGBA()
+}
+
+// medoid returns the color of colors with the smallest weighted sum of
+// distances to the others, among the heaviest maxMedoidCandidates colors.
+func medoid(colors []point) color.RGBA {
+	return medoidPoint(colors).RGBA()
+}
+
+// medoidPoint is like medoid but returns all the features of the point.
+// Distances are measured between colors only.
+func medoidPoint(colors []point) vector {
+	candidates := colors
+	if len(candidates) > maxMedoidCandidates {
+		candidates = append([]point(nil), colors...)
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].weight > candidates[j].weight })
+		candidates = candidates[:maxMedoidCandidates]
+	}
+	var best vector
+	bestCost := math.Inf(1)
+	for _, c := range candidates {
+		var cost float64
+		for _, p := range colors {
+			d0, d1, d2 := c.v[0]-p.v[0], c.v[1]-p.v[1], c.v[2]-p.v[2]
+			cost += p.weight * math.Sqrt(d0*d0+d1*d1+d2*d2)
+		}
+		if cost < bestCost {
+			best, bestCost = c.v, cost
+		}
+	}
+	return best
+}
+
+// mostChromatic returns the color of colors with the highest chroma. Ties
+// are broken by weight.
+func mostChromatic(colors []point) color.RGBA {
+	var best point
+	bestChroma := -1.0
+	for _, p := range colors {
+		c := p.v.RGBA()
+		chroma := rgbToLab(c.R, c.G, c.B).LCh().C
+		if chroma > bestChroma || chroma == bestChroma && p.weight > best.weight {
+			best, bestChroma = p, chroma
+		}
+	}
+	return best.v.RGBA()
+}
@@ -49,18 +49,21 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"math/rand"
 	"sort"
+	"strings"
 
 	"golang.org/x/image/draw"
 )
 
 const (
-	resizeTo      = 256
-	maxSample     = 10
-	nIterations   = 50
-	maxBrightness = 665
-	minDarkness   = 100
+	resizeTo = 256
+	// Working size with WithResolutionStable. Images are shrunk further so
+	// that small thumbnails are analyzed at the same scale as large images.
+	stableResizeTo = 64
+	maxSample      = 10
+	nIterations    = 50
+	maxBrightness  = 665
+	minDarkness    = 100
 
 	nClustersDefault = 4
 )
@@ -70,79 +73,286 @@ type Color struct {
 	Weight float64
 }
 
-func findClusters(img image.Image, nCluster int) (kMeanClusterGroup, float64) {
+// findClusters returns the clusters of the colors of img sorted by weight,
+// the points of the working image they were computed from and the total
+// weight of the points.
+func findClusters(img image.Image, nCluster int, o *options) (kMeanClusterGroup, []point, float64) {
+	mask := newWeightMask(img, o)
 	// Shrink image for faster processing.
-	img = resizeIfLarge(img)
+	end := o.span(PhaseResize)
+	img = o.resize(img)
+	end()
 
 	bounds := img.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
-	rnd := rand.New(rand.NewSource(0))
-	randomPoint := func() (x, y int) {
-		x = bounds.Min.X + rnd.Intn(width)
-		y = bounds.Min.Y + rnd.Intn(height)
-		return
-	}
-	// Pick a starting point for each cluster.
-	clusters := make(kMeanClusterGroup, 0, nCluster)
-	for i := 0; i < nCluster; i++ {
-		// Try up to 10 times to find a unique color. If no unique color can be
-		// found, destroy this cluster.
-		colorUnique := false
-		for j := 0; j < maxSample; j++ {
-			ri, gi, bi, a := img.At(randomPoint()).RGBA()
-			// Ignore transparent pixels.
-			if a == 0 {
-				continue
-			}
-			r, g, b := uint8(ri/0x101), uint8(gi/0x101), uint8(bi/0x101)
-			// Check to see if we have seen this color before.
-			colorUnique = !clusters.ContainsCentroid(r, g, b)
-			// If we have a unique color set the center of the cluster to
-			// that color.
-			if colorUnique {
-				c := new(kMeanCluster)
-				c.SetCentroid(r, g, b)
-				clusters = append(clusters, c)
-				break
-			}
+	end = o.span(PhasePixels)
+	points := imagePoints(img, mask.scaled(bounds), o)
+	end()
+	clusters := clusterPoints(points, width, height, nCluster, nIterations, o)
+	return clusters, points, float64(width) * float64(height)
+}
+
+// clusterPoints runs the KMean algorithm over a row-major grid of points
+// and returns the clusters sorted by weight.
+func clusterPoints(points []point, width, height, nCluster, iterations int, o *options) kMeanClusterGroup {
+	if o.blur > 0 {
+		blurPoints(points, width, height, o.blur)
+	}
+	if o.edgeWeight < 1 {
+		downweightEdges(points, width, height, o.edgeWeight)
+	}
+	if o.superpixels > 0 {
+		segmentSuperpixels(points, width, height, o.superpixels)
+	}
+	if o.sampleSize > 0 && len(points) > o.sampleSize && !o.regionWeight {
+		// The sample is clustered as a single row of points.
+		points = reservoirSample(points, o.sampleSize)
+		width, height = len(points), 1
+	}
+	// Iterate over distinct colors instead of pixels when the position of
+	// pixels does not matter.
+	samples := points
+	if !o.regionWeight && (o.stable || o.exact || o.algorithm == KMedoids || o.histogramFits(len(points))) {
+		samples = histogramPoints(points)
+	}
+	var clusters kMeanClusterGroup
+	switch o.algorithm {
+	case HueSectors:
+		end := o.span(PhaseIterations)
+		clusters = hueSectorClusters(samples, nCluster)
+		end()
+	case KMedoids:
+		clusters = pamClusters(samples, nCluster, iterations, o)
+	default:
+		clusters = kMeanClusters(points, samples, width, height, nCluster, iterations, o)
+	}
+	if o.regionWeight {
+		weighByLargestRegion(clusters, points, width, height)
+	}
+	if o.snap {
+		snapToMembers(clusters, points)
+	}
+	// Sort the clusters by population so we can tell what the most popular
+	// color is.
+	sort.Sort(byWeight(clusters))
+	return clusters
+}
+
+// kMeanClusters seeds clusters from the row-major grid of points and
+// refines them over samples, which hold the same colors as points, for up
+// to the given number of iterations.
+func kMeanClusters(points, samples []point, width, height, nCluster, iterations int, o *options) kMeanClusterGroup {
+	pinned := o.pinnedClusters()
+	if nCluster < len(pinned) {
+		nCluster = len(pinned)
+	}
+	if o.space != SpaceRGB {
+		// Cluster copies of the points in the color space, and convert the
+		// centroids back to sRGB when done.
+		if !o.spacePoints {
+			points = append([]point(nil), points...)
+			samples = append([]point(nil), samples...)
+			o.space.toFeatures(points)
+			o.space.toFeatures(samples)
 		}
-		if !colorUnique {
-			break
+		for _, c := range pinned {
+			o.space.toFeature(&c.centroid)
 		}
 	}
+	end := o.span(PhaseInit)
+	clusters := append(kMeanClusterGroup(nil), pinned...)
+	switch {
+	case len(o.seeds) != 0:
+		clusters = givenSeeds(clusters, o.seeds, o.space)
+	case o.stable || o.exact:
+		clusters = histogramSeeds(clusters, samples, nCluster)
+	case o.seeding == KMeansPlusPlus:
+		clusters = plusPlusSeeds(clusters, samples, nCluster)
+	case o.seeding == StratifiedSeeding:
+		clusters = stratifiedSeeds(clusters, points, width, height, nCluster)
+	default:
+		clusters = randomSeeds(clusters, points, width, height, nCluster)
+	}
+	end()
+	end = o.span(PhaseIterations)
 	convergence := false
-	for i := 0; i < nIterations && !convergence && len(clusters) != 0; i++ {
+	weights := make([]float64, len(clusters))
+	for i := 0; i < iterations && !convergence && len(clusters) != 0; i++ {
+		for j, c := range clusters {
+			weights[j] = c.weight
+		}
+		if o.trim > 0 {
+			convergence = clusters.TrimmedStep(samples, o.trim)
+		} else {
+			convergence = clusters.Step(samples)
+		}
+		if o.weightTolerance > 0 && i > 0 {
+			convergence = convergence || clusters.weightsSettled(weights, o.weightTolerance)
+		}
+	}
+	end()
+	if o.space != SpaceRGB {
+		for _, c := range clusters {
+			o.space.fromFeature(&c.centroid)
+		}
+		// Pinned colors must not be altered by the round trip.
+		for i, c := range o.pinnedClusters() {
+			pinned[i].centroid = c.centroid
+		}
+	}
+	return clusters
+}
+
+// imagePoints converts the pixels of img to clustering points in row-major
+// order. Transparent pixels are kept with zero weight so that the index of
+// a point can be computed from its coordinates. The weight of each point
+// is scaled by mask.
+func imagePoints(img image.Image, mask *weightMask, o *options) []point {
+	return visitImagePoints(img, mask, o, nil)
+}
+
+// visitImagePoints is like imagePoints but also calls visit, if not nil,
+// with the alpha-premultiplied color of each pixel, so that other
+// statistics can be gathered in the same pass over the image.
+func visitImagePoints(img image.Image, mask *weightMask, o *options, visit func(r, g, b, a uint32)) []point {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	points := make([]point, 0, width*height)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-				ri, gi, bi, a := img.At(x, y).RGBA()
-				// Ignore transparent pixels.
-				if a == 0 {
-					continue
-				}
-				r, g, b := uint8(ri/0x101), uint8(gi/0x101), uint8(bi/0x101)
-				// Figure out which cluster this color is closest to in RGB space.
-				closest := clusters.Closest(r, g, b)
-				closest.AddPoint(r, g, b)
+			r, g, b, a := rgbaAt(img, x, y)
+			if visit != nil {
+				visit(r, g, b, a)
 			}
+			p := o.colorPoint(r, g, b, a)
+			p.weight *= mask.weight(x, y)
+			if o.spatialWeight > 0 {
+				p.v[3] = normalizedCoordinate(x-bounds.Min.X, width) * o.spatialWeight
+				p.v[4] = normalizedCoordinate(y-bounds.Min.Y, height) * o.spatialWeight
+			}
+			points = append(points, p)
 		}
-		// Calculate the new cluster centers and see if we've converged or not.
-		convergence = true
-		for _, c := range clusters {
-			convergence = convergence && c.CompareCentroidWithAggregate()
-			c.RecomputeCentroid()
+	}
+	return points
+}
+
+// rgbaAt returns the alpha-premultiplied color of the pixel at (x, y).
+// Common image types are accessed without allocating a color.Color.
+func rgbaAt(img image.Image, x, y int) (r, g, b, a uint32) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		return img.NRGBAAt(x, y).RGBA()
+	case *image.RGBA:
+		return img.RGBAAt(x, y).RGBA()
+	case *image.CMYK:
+		return img.CMYKAt(x, y).RGBA()
+	case *image.YCbCr:
+		return img.YCbCrAt(x, y).RGBA()
+	}
+	return img.At(x, y).RGBA()
+}
+
+// colorPoint converts a pixel color to a clustering point without spatial
+// features.
+func (o *options) colorPoint(ri, gi, bi, a uint32) point {
+	if o.unpremultiply && a != 0 && a != 0xffff {
+		ri, gi, bi = ri*0xffff/a, gi*0xffff/a, bi*0xffff/a
+	}
+	mask := uint32(0xff << (8 - o.quantizeBits) & 0xff)
+	var p point
+	p.v[0] = float64(ri / 0x101 & mask)
+	p.v[1] = float64(gi / 0x101 & mask)
+	p.v[2] = float64(bi / 0x101 & mask)
+	// Ignore transparent pixels.
+	if a != 0 && a >= uint32(o.minAlpha)*0x101 {
+		p.weight = 1
+	}
+	if (len(o.excludeHues) > 0 || len(o.ignore) > 0) && p.weight != 0 {
+		c := color.RGBA{R: uint8(ri / 0x101), G: uint8(gi / 0x101), B: uint8(bi / 0x101)}
+		if h, s, _ := hsl(c); s >= neutralSaturation && o.excludedHue(h) || o.ignored(c) {
+			p.weight = 0
 		}
 	}
-	// Sort the clusters by population so we can tell what the most popular
-	// color is.
-	sort.Sort(byWeight(clusters))
-	return clusters, float64(width) * float64(height)
+	return p
 }
 
-func resizeIfLarge(img image.Image) image.Image {
-	srcBounds := img.Bounds()
-	if srcBounds.Dx() <= resizeTo && srcBounds.Dy() <= resizeTo {
+// RGBA returns the opaque color of the color features of v.
+func (v vector) RGBA() color.RGBA {
+	return color.RGBA{R: uint8(v[0]), G: uint8(v[1]), B: uint8(v[2]), A: 0xff}
+}
+
+// normalizedCoordinate maps the center of pixel i in a row of n pixels to
+// the range of a color channel.
+func normalizedCoordinate(i, n int) float64 {
+	return (float64(i) + 0.5) / float64(n) * 0xff
+}
+
+// WorkingImage returns img shrunk to the size it is analyzed at with the
+// given options, or img itself if it is small enough. Thumbnailing
+// services can reuse it as a thumbnail and analyze it with WithoutResize
+// to avoid scaling the image twice:
+//
+//	thumb := dominantcolor.WorkingImage(img)
+//	c := dominantcolor.Find(thumb, dominantcolor.WithoutResize())
+func WorkingImage(img image.Image, opts ...Option) image.Image {
+	if isNil(img) {
+		return img
+	}
+	return newOptions(opts).resize(img)
+}
+
+// Resizer shrinks images to the working size, to use a faster scaler than
+// the pure Go one of this package, such as one backed by libvips or a GPU.
+type Resizer interface {
+	// Fit returns img scaled to w x h pixels. The colors of the pixels of
+	// the result should be present in img or blended from them.
+	Fit(img image.Image, w, h int) image.Image
+}
+
+// ResizerFunc is an adapter to allow the use of ordinary functions as
+// Resizers.
+type ResizerFunc func(img image.Image, w, h int) image.Image
+
+// Fit returns f(img, w, h).
+func (f ResizerFunc) Fit(img image.Image, w, h int) image.Image {
+	return f(img, w, h)
+}
+
+// resize shrinks img to the working size if it is larger.
+func (o *options) resize(img image.Image) image.Image {
+	if o.noResize {
+		return img
+	}
+	dstBounds, ok := fitBounds(img.Bounds(), o.workingSize())
+	if !ok {
 		return img // already small enough
 	}
+	if o.resizer != nil {
+		return o.resizer.Fit(img, dstBounds.Dx(), dstBounds.Dy())
+	}
+	var scaler draw.Scaler = draw.NearestNeighbor
+	if o.stable && !o.exact {
+		scaler = boxFilter
+	}
+	dst := image.NewNRGBA(dstBounds)
+	scaler.Scale(dst, dstBounds, img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// boxFilter averages the area of the source image covered by each
+// destination pixel when downscaling.
+var boxFilter = &draw.Kernel{
+	Support: 0.5,
+	At:      func(t float64) float64 { return 1 },
+}
+
+// fitBounds returns the bounds of the image srcBounds is shrunk to so that
+// it fits in a square of resizeTo pixels, keeping its aspect ratio. It
+// returns false if the image already fits.
+func fitBounds(srcBounds image.Rectangle, resizeTo int) (image.Rectangle, bool) {
+	if srcBounds.Dx() <= resizeTo && srcBounds.Dy() <= resizeTo {
+		return srcBounds, false
+	}
 
 	aspect := float64(srcBounds.Dx()) / float64(srcBounds.Dy())
 	var newW, newH int
@@ -153,40 +363,36 @@ func resizeIfLarge(img image.Image) image.Image {
 		newH = resizeTo
 		newW = int(float64(newH) * aspect)
 	}
-
-	dstBounds := image.Rect(0, 0, newW, newH)
-	dst := image.NewNRGBA(dstBounds)
-	draw.NearestNeighbor.Scale(dst, dstBounds, img, srcBounds, draw.Src, nil)
-	return dst
+	// Keep at least one pixel of extremely thin images.
+	if newW < 1 {
+		newW = 1
+	}
+	if newH < 1 {
+		newH = 1
+	}
+	return image.Rect(0, 0, newW, newH), true
 }
 
 // Find returns the dominant color in img.
-func Find(img image.Image) color.RGBA {
-	colors := FindN(img, nClustersDefault)
-	if len(colors) == 0 {
-		return color.RGBA{0, 0, 0, 0}
-	}
-	// Loop through the clusters to figure out which cluster has an appropriate
-	// color. Skip any that are too bright/dark and go in order of weight.
-	for _, c := range colors {
-		// Sum the RGB components to determine if the color is too bright or too dark.
-		summedColor := uint16(c.R) + uint16(c.G) + uint16(c.B)
+func Find(img image.Image, opts ...Option) color.RGBA {
+	return newOptions(opts).pick(FindWeight(img, nClustersDefault, opts...))
+}
 
-		if summedColor < maxBrightness && summedColor > minDarkness {
-			// If we found a valid color just set it and break. We don't want to
-			// check the other ones.
-			return c
-		}
-	}
-	// We haven't found a valid color, return the first one.
-	return colors[0]
+// pick returns the color Find picks among colors sorted by weight.
+func (o *options) pick(colors []Color) color.RGBA {
+	c, _ := o.evaluate(colors)
+	return c
 }
 
 // FindN returns the first-N dominant colors in an image.
 // If nClusters is less than or equal to 0, the value defaults to 4.
-// Clusters are returned in their order of dominance.
-func FindN(img image.Image, nClusters int) []color.RGBA {
-	colors := FindWeight(img, nClusters)
+// Clusters are returned in their order of dominance. If the input is
+// rejected by ValidateInput, an empty slice is returned.
+func FindN(img image.Image, nClusters int, opts ...Option) []color.RGBA {
+	return rgbaColors(FindWeight(img, nClusters, opts...))
+}
+
+func rgbaColors(colors []Color) []color.RGBA {
 	cols := []color.RGBA{}
 	for _, c := range colors {
 		cols = append(cols, c.RGBA)
@@ -194,19 +400,34 @@ func FindN(img image.Image, nClusters int) []color.RGBA {
 	return cols
 }
 
-func FindWeight(img image.Image, nClusters int) []Color {
+// FindWeight is like FindN but also returns the fraction of the image
+// covered by each color.
+func FindWeight(img image.Image, nClusters int, opts ...Option) []Color {
 	if nClusters <= 0 {
 		nClusters = nClustersDefault
 	}
+	if ValidateInput(img, nClusters) != nil {
+		return []Color{}
+	}
 
-	clusters, totalWeight := findClusters(img, nClusters)
+	o := newOptions(opts)
+	if ycc, ok := o.ycbcrSource(img); ok {
+		clusters, totalWeight := findYCbCrClusters(ycc, nClusters, o)
+		return clusterColors(clusters, totalWeight)
+	}
+	clusters, _, totalWeight := findClusters(img, nClusters, o)
+	return clusterColors(clusters, totalWeight)
+}
 
+// clusterColors converts clusters to colors weighted by their share of
+// totalWeight.
+func clusterColors(clusters kMeanClusterGroup, totalWeight float64) []Color {
 	colors := []Color{}
 	for _, c := range clusters {
 		r, g, b := c.Centroid()
 		colors = append(colors, Color{
 			RGBA:   color.RGBA{R: r, G: g, B: b, A: 0xff},
-			Weight: float64(c.weight) / totalWeight,
+			Weight: c.weight / totalWeight,
 		})
 	}
 	return colors
@@ -216,3 +437,29 @@ func FindWeight(img image.Image, nClusters int) []Color {
 func Hex(c color.RGBA) string {
 	return "#" + fmt.Sprintf("%.2X%.2X%.2X", c.R, c.G, c.B)
 }
+
+// ParseHex parses a color in "#AABBCC" format, as returned by Hex. The
+// leading "#" is optional.
+func ParseHex(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	c := color.RGBA{A: 0xff}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("dominantcolor: invalid hex color %q", s)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return color.RGBA{}, fmt.Errorf("dominantcolor: invalid hex color %q", s)
+	}
+	return c, nil
+}
+
+func parseHexes(hexes []string) ([]color.RGBA, error) {
+	colors := make([]color.RGBA, 0, len(hexes))
+	for _, h := range hexes {
+		c, err := ParseHex(h)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
@@ -1,16 +1,30 @@
 package dominantcolor_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
 	"image/png"
 	_ "image/png"
+	"io"
 	"math"
+	"math/rand"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cenkalti/dominantcolor"
+	"github.com/cenkalti/dominantcolor/palettetest"
+	xdraw "golang.org/x/image/draw"
 )
 
 // https://www.mozilla.org/en-US/styleguide/identity/firefox/color/
@@ -118,3 +132,2792 @@ func BenchmarkFind(b *testing.B) {
 		dominantcolor.Find(img)
 	}
 }
+
+func TestFindWeight_Spatial(t *testing.T) {
+	top := color.RGBA{R: 230, G: 96, A: 255}
+	bottom := color.RGBA{B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if y < 30 {
+				img.SetRGBA(x, y, top)
+			} else {
+				img.SetRGBA(x, y, bottom)
+			}
+		}
+	}
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithSpatialWeight(2))
+	if len(colors) != 2 {
+		t.Fatal("Did not find 2 colors. Got:", len(colors))
+	}
+	if colors[0].RGBA != top || colors[1].RGBA != bottom {
+		t.Errorf("Unexpected colors: %s, %s", dominantcolor.Hex(colors[0].RGBA), dominantcolor.Hex(colors[1].RGBA))
+	}
+	if math.Abs(colors[0].Weight-0.75) > 0.01 {
+		t.Errorf("Unexpected weight: %.2f", colors[0].Weight)
+	}
+}
+
+func TestFindWeight_Superpixels(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithSuperpixels(200))
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	if total > 1.0001 {
+		t.Errorf("Total weight is greater than 1: %.4f", total)
+	}
+	c := dominantcolor.Find(img, dominantcolor.WithSuperpixels(200))
+	if d := distance(c, firefoxOrange); d > 50 {
+		t.Errorf("Found color is not close: %s, distance %.2f", dominantcolor.Hex(c), d)
+	}
+}
+
+func TestFindWeight_SuperpixelsThin(t *testing.T) {
+	// A checkerboard of close reds is segmented into superpixels of their
+	// mean color, even when the image is thinner than the superpixels.
+	img := image.NewRGBA(image.Rect(0, 0, 256, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 256; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 30, B: 30, A: 255})
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.RGBA{R: 210, G: 40, B: 40, A: 255})
+			}
+		}
+	}
+	for _, c := range dominantcolor.FindWeight(img, 2, dominantcolor.WithSuperpixels(4)) {
+		if c.R <= 200 || c.R >= 210 {
+			t.Errorf("Superpixels are not segmented: %v", c)
+		}
+	}
+}
+
+func TestFindWeight_RegionWeight(t *testing.T) {
+	solid := color.RGBA{R: 230, G: 96, A: 255}
+	dithered := color.RGBA{B: 200, A: 255}
+	background := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			switch {
+			case x < 10:
+				img.SetRGBA(x, y, solid)
+			case (x+y)%2 == 0:
+				img.SetRGBA(x, y, dithered)
+			default:
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
+	colors := dominantcolor.FindWeight(img, 3)
+	if colors[0].RGBA == solid {
+		t.Fatal("Solid color should not dominate without region weighting")
+	}
+	colors = dominantcolor.FindWeight(img, 3, dominantcolor.WithRegionWeight())
+	if colors[0].RGBA != solid {
+		t.Errorf("Unexpected dominant color: %s", dominantcolor.Hex(colors[0].RGBA))
+	}
+}
+
+func TestFindWeight_EdgeWeight(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4)
+	edgeless := dominantcolor.FindWeight(img, 4, dominantcolor.WithEdgeWeight(0))
+	if len(edgeless) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(edgeless))
+	}
+	var total, edgelessTotal float64
+	for i := range colors {
+		total += colors[i].Weight
+		edgelessTotal += edgeless[i].Weight
+	}
+	if edgelessTotal >= total {
+		t.Errorf("Ignoring edges did not reduce total weight: %.4f >= %.4f", edgelessTotal, total)
+	}
+}
+
+func TestFindWeight_QuantizeBits(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithQuantizeBits(3))
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	c := dominantcolor.Find(img, dominantcolor.WithQuantizeBits(3))
+	if d := distance(c, firefoxOrange); d > 50 {
+		t.Errorf("Found color is not close: %s, distance %.2f", dominantcolor.Hex(c), d)
+	}
+}
+
+func TestFindFast(t *testing.T) {
+	for _, img := range []image.Image{testImage(t), largeTestImage(t)} {
+		c := dominantcolor.FindFast(img)
+		want := dominantcolor.Find(img)
+		if d := distance(c, want); d > 50 {
+			t.Errorf("Found color is not close: %s, want %s, distance %.2f", dominantcolor.Hex(c), dominantcolor.Hex(want), d)
+		}
+	}
+}
+
+func BenchmarkFindFast(b *testing.B) {
+	f, err := os.Open("firefox.png")
+	if err != nil {
+		b.Fatal(err)
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		b.Fatal(err)
+	}
+	f.Close()
+	for i := 0; i < b.N; i++ {
+		dominantcolor.FindFast(img)
+	}
+}
+
+func TestFind_MaxMemory(t *testing.T) {
+	img := largeTestImage(t)
+	c := dominantcolor.Find(img, dominantcolor.WithMaxMemory(1<<20))
+	d := distance(c, firefoxLargeDominant)
+	if d > 50 {
+		t.Errorf("Found color is not close: %s, distance %.2f", dominantcolor.Hex(c), d)
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	var nilRGBA *image.RGBA
+	tests := []struct {
+		name      string
+		img       image.Image
+		nClusters int
+		err       error
+	}{
+		{"nil", nil, 4, dominantcolor.ErrNilImage},
+		{"typed nil", nilRGBA, 4, dominantcolor.ErrNilImage},
+		{"empty", image.NewRGBA(image.Rect(0, 0, 0, 10)), 4, dominantcolor.ErrEmptyImage},
+		{"valid", image.NewRGBA(image.Rect(0, 0, 1, 1)), 4, nil},
+	}
+	for _, test := range tests {
+		if err := dominantcolor.ValidateInput(test.img, test.nClusters); err != test.err {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+		if test.err != nil && len(dominantcolor.FindWeight(test.img, test.nClusters)) != 0 {
+			t.Errorf("%s: got colors for invalid input", test.name)
+		}
+	}
+	err := dominantcolor.ValidateInput(image.NewRGBA(image.Rect(0, 0, 1, 1)), 1<<40)
+	if _, ok := err.(*dominantcolor.ClusterCountError); !ok {
+		t.Errorf("got error %v, want ClusterCountError", err)
+	}
+}
+
+func FuzzFindWeight(f *testing.F) {
+	f.Add(uint8(4), uint8(4), 4, uint8(0), uint8(0), []byte{255, 0, 0, 255, 0, 0, 255, 128})
+	f.Add(uint8(1), uint8(200), 1, uint8(0xff), uint8(0), []byte{0, 0, 0, 0})
+	f.Add(uint8(4), uint8(4), 4, uint8(0), uint8(1), []byte{255, 255, 255, 1})
+	f.Add(uint8(5), uint8(3), 2, uint8(0), uint8(2|4), []byte{16, 240, 16, 235, 128, 128})
+	f.Fuzz(func(t *testing.T, w, h uint8, nClusters int, flags, kind uint8, pix []byte) {
+		r := image.Rect(0, 0, int(w), int(h))
+		var img image.Image
+		switch kind & 3 {
+		case 0:
+			nrgba := image.NewNRGBA(r)
+			copy(nrgba.Pix, pix)
+			img = nrgba
+		case 1:
+			// Channels may exceed alpha, which is not a valid
+			// premultiplied color.
+			rgba := image.NewRGBA(r)
+			copy(rgba.Pix, pix)
+			img = rgba
+		case 2:
+			ycc := image.NewYCbCr(r, image.YCbCrSubsampleRatio420)
+			n := copy(ycc.Y, pix)
+			n += copy(ycc.Cb, pix[n:])
+			copy(ycc.Cr, pix[n:])
+			img = ycc
+		case 3:
+			cmyk := image.NewCMYK(r)
+			copy(cmyk.Pix, pix)
+			img = cmyk
+		}
+		var opts []dominantcolor.Option
+		if flags&1 != 0 {
+			opts = append(opts, dominantcolor.WithSpatialWeight(1))
+		}
+		if flags&2 != 0 {
+			opts = append(opts, dominantcolor.WithSuperpixels(int(flags)))
+		}
+		if flags&4 != 0 {
+			opts = append(opts, dominantcolor.WithRegionWeight())
+		}
+		if flags&8 != 0 {
+			opts = append(opts, dominantcolor.WithEdgeWeight(0))
+		}
+		if flags&16 != 0 {
+			opts = append(opts, dominantcolor.WithQuantizeBits(int(flags>>5)))
+		}
+		if flags&32 != 0 {
+			opts = append(opts, dominantcolor.WithMaxMemory(int64(len(pix))))
+		}
+		if kind&4 != 0 {
+			opts = append(opts, dominantcolor.WithColorSpace(dominantcolor.SpaceYCbCr))
+		}
+		if kind&8 != 0 {
+			opts = append(opts, dominantcolor.ForLogos())
+		}
+		if kind&16 != 0 {
+			opts = append(opts, dominantcolor.ForPhotos())
+		}
+		if kind&32 != 0 {
+			opts = append(opts, dominantcolor.WithAlgorithm(dominantcolor.KMedoids))
+		}
+		if kind&64 != 0 {
+			opts = append(opts, dominantcolor.WithAlgorithm(dominantcolor.HueSectors))
+		}
+		if kind&128 != 0 {
+			opts = append(opts, dominantcolor.WithSalience(), dominantcolor.WithPresentColors())
+		}
+		colors := dominantcolor.FindWeight(img, nClusters, opts...)
+		if len(colors) > 0 && dominantcolor.ValidateInput(img, nClusters) != nil {
+			t.Error("Got colors for invalid input")
+		}
+		dominantcolor.Find(img, opts...)
+		dominantcolor.FindFast(img, opts...)
+		dominantcolor.FindTrace(img, opts...)
+		dominantcolor.Analyze(img, opts...)
+		dominantcolor.FindProduct(img, nClusters, opts...)
+		dominantcolor.FindCMYK(img, nClusters, opts...)
+		dominantcolor.EstimateDistinctColors(img, opts...)
+		dominantcolor.Validate(img, colors, opts...)
+		if nClusters <= 2 {
+			pages, _ := dominantcolor.FindPages(nClusters, func(int) (image.Image, error) { return img, nil }, opts...)
+			if nClusters <= 0 && len(pages) != 0 || nClusters > 0 && len(pages) != nClusters {
+				t.Errorf("Got %d pages, want %d", len(pages), nClusters)
+			}
+		}
+	})
+}
+
+func TestFindWeight_AlphaThreshold(t *testing.T) {
+	sprite := color.NRGBA{R: 230, G: 96, A: 255}
+	halo := color.NRGBA{B: 200, A: 8}
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x < 8 {
+				img.SetNRGBA(x, y, sprite)
+			} else {
+				img.SetNRGBA(x, y, halo)
+			}
+		}
+	}
+	want := color.RGBA{R: 230, G: 96, A: 255}
+	if colors := dominantcolor.FindWeight(img, 2); colors[0].RGBA == want {
+		t.Fatal("Halo should dominate without alpha threshold")
+	}
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithAlphaThreshold(16))
+	if len(colors) != 1 {
+		t.Fatal("Did not find 1 color. Got:", len(colors))
+	}
+	if colors[0].RGBA != want {
+		t.Errorf("Unexpected color: %s", dominantcolor.Hex(colors[0].RGBA))
+	}
+}
+
+func TestFindWeight_Masker(t *testing.T) {
+	subject := color.RGBA{R: 230, G: 96, A: 255}
+	background := color.RGBA{B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x >= 8 && x < 12 && y >= 8 && y < 12 {
+				img.SetRGBA(x, y, subject)
+			} else {
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
+	// The mask is computed at a lower resolution than the image.
+	masker := dominantcolor.MaskerFunc(func(image.Image) *image.Alpha {
+		mask := image.NewAlpha(image.Rect(0, 0, 5, 5))
+		mask.SetAlpha(2, 2, color.Alpha{A: 255})
+		return mask
+	})
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithMasker(masker))
+	if len(colors) != 1 || colors[0].RGBA != subject {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	if c := dominantcolor.FindFast(img, dominantcolor.WithMasker(masker)); c != subject {
+		t.Errorf("Unexpected color: %s", dominantcolor.Hex(c))
+	}
+}
+
+func TestFindWeight_Regions(t *testing.T) {
+	face := color.RGBA{R: 230, G: 96, A: 255}
+	background := color.RGBA{B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	faceRect := image.Rect(200, 100, 500, 350)
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 600; x++ {
+			if image.Pt(x, y).In(faceRect) {
+				img.SetRGBA(x, y, face)
+			} else {
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithExcludeRegions(faceRect))
+	if len(colors) != 1 || colors[0].RGBA != background {
+		t.Errorf("Unexpected colors with face excluded: %v", colors)
+	}
+	colors = dominantcolor.FindWeight(img, 2, dominantcolor.WithIncludeRegions(faceRect))
+	if len(colors) != 1 || colors[0].RGBA != face {
+		t.Errorf("Unexpected colors with face included: %v", colors)
+	}
+}
+
+func TestFindRegions(t *testing.T) {
+	img := largeTestImage(t)
+	bounds := img.Bounds()
+	half := bounds.Dx() / 2
+	regions := []image.Rectangle{
+		bounds,
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+half, bounds.Max.Y),
+		image.Rect(-10, -10, -1, -1),
+	}
+	palettes := dominantcolor.FindRegions(img, regions, 4)
+	if len(palettes) != len(regions) {
+		t.Fatal("Unexpected number of palettes:", len(palettes))
+	}
+	whole := dominantcolor.FindWeight(img, 4)
+	for i := range whole {
+		if palettes[0][i] != whole[i] {
+			t.Errorf("Palette of whole image differs at %d: %v != %v", i, palettes[0][i], whole[i])
+		}
+	}
+	if len(palettes[1]) == 0 {
+		t.Error("No colors found in left half")
+	}
+	if len(palettes[2]) != 0 {
+		t.Error("Found colors outside of image")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			switch {
+			case x < 6:
+				img.SetRGBA(x, y, color.RGBA{R: 230 + uint8(y%4), G: 96, A: 255})
+			case x < 9:
+				img.SetRGBA(x, y, color.RGBA{B: 200, A: 255})
+			}
+		}
+	}
+	bins := dominantcolor.Histogram(img, 4)
+	want := []dominantcolor.BinCount{
+		{RGBA: color.RGBA{R: 224, G: 96, A: 255}, Count: 60},
+		{RGBA: color.RGBA{B: 192, A: 255}, Count: 30},
+	}
+	if len(bins) != len(want) {
+		t.Fatalf("Unexpected bins: %v", bins)
+	}
+	for i := range want {
+		if bins[i] != want[i] {
+			t.Errorf("Unexpected bin %d: %v, want %v", i, bins[i], want[i])
+		}
+	}
+}
+
+func TestHistogramDistances(t *testing.T) {
+	red := color.RGBA{R: 200, G: 32, B: 32, A: 255}
+	blue := color.RGBA{R: 32, G: 32, B: 200, A: 255}
+	green := color.RGBA{R: 32, G: 200, B: 32, A: 255}
+	a := []dominantcolor.BinCount{{RGBA: red, Count: 3}, {RGBA: blue, Count: 1}}
+	b := []dominantcolor.BinCount{{RGBA: blue, Count: 10}, {RGBA: red, Count: 10}}
+	c := []dominantcolor.BinCount{{RGBA: green, Count: 1}}
+	for _, tc := range []struct {
+		name     string
+		distance func(a, b []dominantcolor.BinCount) float64
+		want     float64
+	}{
+		{"ChiSquare", dominantcolor.ChiSquareDistance, 1.0 / 15},
+		{"Bhattacharyya", dominantcolor.BhattacharyyaDistance, math.Sqrt(1 - math.Sqrt(0.375) - math.Sqrt(0.125))},
+		{"Intersection", dominantcolor.IntersectionDistance, 0.25},
+	} {
+		if d := tc.distance(a, b); math.Abs(d-tc.want) > 1e-9 {
+			t.Errorf("%s distance = %f, want %f", tc.name, d, tc.want)
+		}
+		if d := tc.distance(a, a); math.Abs(d) > 1e-7 {
+			t.Errorf("%s distance to itself = %f", tc.name, d)
+		}
+		if d := tc.distance(a, c); d != 1 {
+			t.Errorf("%s distance of disjoint histograms = %f", tc.name, d)
+		}
+		if d := tc.distance(a, nil); d != 1 {
+			t.Errorf("%s distance to empty histogram = %f", tc.name, d)
+		}
+	}
+}
+
+func TestPaletteEntropy(t *testing.T) {
+	tests := []struct {
+		weights []float64
+		entropy float64
+	}{
+		{nil, 0},
+		{[]float64{1}, 0},
+		{[]float64{0.5, 0.5}, 1},
+		{[]float64{0.25, 0.25, 0.25, 0.25}, 2},
+		{[]float64{1, 1}, 1},
+	}
+	for _, test := range tests {
+		colors := make([]dominantcolor.Color, len(test.weights))
+		for i, w := range test.weights {
+			colors[i].Weight = w
+		}
+		if e := dominantcolor.PaletteEntropy(colors); math.Abs(e-test.entropy) > 1e-9 {
+			t.Errorf("PaletteEntropy(%v) = %f, want %f", test.weights, e, test.entropy)
+		}
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	flat := image.NewUniform(color.RGBA{R: 230, G: 96, A: 255})
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(logo, logo.Bounds(), flat, image.Point{}, draw.Src)
+	if c := dominantcolor.Complexity(logo); c != 0 {
+		t.Errorf("Complexity of a flat image is %f", c)
+	}
+	if c := dominantcolor.Complexity(largeTestImage(t)); c <= 0.1 || c > 1 {
+		t.Errorf("Unexpected complexity of a photo: %f", c)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors []dominantcolor.Color
+		want   []dominantcolor.Archetype
+	}{
+		{"pastel", []dominantcolor.Color{
+			{RGBA: color.RGBA{R: 255, G: 209, B: 220, A: 255}, Weight: 0.6},
+			{RGBA: color.RGBA{R: 193, G: 225, B: 236, A: 255}, Weight: 0.4},
+		}, []dominantcolor.Archetype{dominantcolor.Pastel}},
+		{"vivid", []dominantcolor.Color{
+			{RGBA: color.RGBA{R: 230, G: 20, A: 255}, Weight: 0.5},
+			{RGBA: color.RGBA{G: 200, B: 30, A: 255}, Weight: 0.5},
+		}, []dominantcolor.Archetype{dominantcolor.Vivid}},
+		{"dark", []dominantcolor.Color{
+			{RGBA: color.RGBA{R: 20, G: 20, B: 40, A: 255}, Weight: 0.9},
+		}, []dominantcolor.Archetype{dominantcolor.Dark}},
+		{"high-contrast", []dominantcolor.Color{
+			{RGBA: color.RGBA{A: 255}, Weight: 0.5},
+			{RGBA: color.RGBA{R: 255, G: 255, B: 255, A: 255}, Weight: 0.5},
+		}, []dominantcolor.Archetype{dominantcolor.Muted, dominantcolor.HighContrast}},
+		{"empty", nil, nil},
+	}
+	for _, test := range tests {
+		got := dominantcolor.Classify(test.colors)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSplitTemperature(t *testing.T) {
+	colors := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 96, A: 255}, Weight: 0.4},
+		{RGBA: color.RGBA{R: 200, G: 20, B: 80, A: 255}, Weight: 0.1},
+		{RGBA: color.RGBA{G: 80, B: 200, A: 255}, Weight: 0.3},
+		{RGBA: color.RGBA{R: 128, G: 128, B: 128, A: 255}, Weight: 0.2},
+	}
+	got := dominantcolor.SplitTemperature(colors)
+	want := dominantcolor.Temperature{Warm: 0.5, Cool: 0.3, Neutral: 0.2}
+	if math.Abs(got.Warm-want.Warm) > 1e-9 || math.Abs(got.Cool-want.Cool) > 1e-9 || math.Abs(got.Neutral-want.Neutral) > 1e-9 {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFindDiff(t *testing.T) {
+	backdrop := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+	product := color.RGBA{R: 230, G: 96, A: 255}
+	reference := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(reference, reference.Bounds(), image.NewUniform(backdrop), image.Point{}, draw.Src)
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backdrop), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(10, 10, 30, 30), image.NewUniform(product), image.Point{}, draw.Src)
+
+	colors := dominantcolor.FindDiff(img, reference, 2)
+	if len(colors) != 1 || colors[0].RGBA != product {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	if math.Abs(colors[0].Weight-0.25) > 1e-9 {
+		t.Errorf("Unexpected weight: %f", colors[0].Weight)
+	}
+}
+
+func TestFindDiff_Stripes(t *testing.T) {
+	// Thin stripes only match if the pixels of the image are compared
+	// with the pixels of the reference at the same position.
+	stripes := func() *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 1001, 601))
+		for y := 0; y < 601; y++ {
+			for x := 0; x < 1001; x++ {
+				img.SetRGBA(x, y, color.RGBA{R: uint8(x % 2 * 255), G: uint8(x % 2 * 255), B: uint8(x % 2 * 255), A: 255})
+			}
+		}
+		return img
+	}
+	product := color.RGBA{R: 230, G: 96, A: 255}
+	img := stripes()
+	draw.Draw(img, image.Rect(300, 200, 700, 400), image.NewUniform(product), image.Point{}, draw.Src)
+	colors := dominantcolor.FindDiff(img, stripes(), 2)
+	if len(colors) != 1 || colors[0].RGBA != product {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	img := testImage(t)
+	acc := dominantcolor.NewAccumulator()
+	acc.Add(img)
+	acc.Add(img)
+	colors := acc.Palette(4)
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	c := dominantcolor.Find(img)
+	if d := distance(colors[0].RGBA, c); d > 50 {
+		t.Errorf("Accumulated color %s is not close to %s", dominantcolor.Hex(colors[0].RGBA), dominantcolor.Hex(c))
+	}
+	acc.Reset()
+	if colors := acc.Palette(4); len(colors) != 0 {
+		t.Errorf("Found colors after reset: %v", colors)
+	}
+}
+
+func TestAccumulator_FrameDifferencing(t *testing.T) {
+	background := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	subject := color.RGBA{R: 230, G: 96, A: 255}
+	acc := dominantcolor.NewAccumulator(dominantcolor.WithFrameDifferencing())
+	for i := 0; i < 5; i++ {
+		frame := image.NewRGBA(image.Rect(0, 0, 40, 40))
+		draw.Draw(frame, frame.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+		if i > 0 {
+			draw.Draw(frame, image.Rect(i*8, 10, i*8+8, 18), image.NewUniform(subject), image.Point{}, draw.Src)
+		}
+		acc.Add(frame)
+	}
+	colors := acc.Palette(2)
+	if len(colors) != 1 || colors[0].RGBA != subject {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+}
+
+func TestMatchPalettes(t *testing.T) {
+	red := color.RGBA{R: 230, G: 20, B: 20, A: 255}
+	green := color.RGBA{R: 20, G: 200, B: 20, A: 255}
+	blue := color.RGBA{R: 20, G: 20, B: 200, A: 255}
+	a := []dominantcolor.Color{{RGBA: red}, {RGBA: green}, {RGBA: blue}}
+	b := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 20, G: 30, B: 190, A: 255}},
+		{RGBA: color.RGBA{R: 220, G: 30, B: 20, A: 255}},
+		{RGBA: color.RGBA{R: 30, G: 190, B: 30, A: 255}},
+		{RGBA: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+	pairs := dominantcolor.MatchPalettes(a, b)
+	want := []int{1, 2, 0}
+	if len(pairs) != len(want) {
+		t.Fatalf("Unexpected pairs: %v", pairs)
+	}
+	for i, p := range pairs {
+		if p.IndexA != i || p.IndexB != want[i] {
+			t.Errorf("Unexpected pair %d: %+v", i, p)
+		}
+		if p.DeltaE > 10 {
+			t.Errorf("Unexpected distance of pair %d: %f", i, p.DeltaE)
+		}
+	}
+	// Swapping the palettes swaps the pairs.
+	for _, p := range dominantcolor.MatchPalettes(b, a) {
+		if want[p.IndexB] != p.IndexA {
+			t.Errorf("Unexpected swapped pair: %+v", p)
+		}
+	}
+}
+
+func TestPaletteDistance(t *testing.T) {
+	a := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 20, B: 20, A: 255}, Weight: 3},
+		{RGBA: color.RGBA{R: 20, G: 20, B: 200, A: 255}, Weight: 1},
+	}
+	b := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 20, G: 20, B: 205, A: 255}, Weight: 2},
+		{RGBA: color.RGBA{R: 228, G: 22, B: 20, A: 255}, Weight: 6},
+	}
+	c := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 20, G: 200, B: 20, A: 255}, Weight: 3},
+		{RGBA: color.RGBA{R: 250, G: 250, B: 250, A: 255}, Weight: 1},
+	}
+	if d := dominantcolor.PaletteDistance(a, a); d != 0 {
+		t.Errorf("Unexpected distance to itself: %f", d)
+	}
+	near, far := dominantcolor.PaletteDistance(a, b), dominantcolor.PaletteDistance(a, c)
+	if near > 5 || far < 50 {
+		t.Errorf("Unexpected distances: %f, %f", near, far)
+	}
+	if d := dominantcolor.PaletteDistance(a, nil); !math.IsInf(d, 1) {
+		t.Errorf("Unexpected distance to empty palette: %f", d)
+	}
+}
+
+func TestFindWeight_ResolutionStable(t *testing.T) {
+	img := largeTestImage(t)
+	want := dominantcolor.FindWeight(img, 4, dominantcolor.WithResolutionStable())
+	for _, size := range []int{512, 200, 128} {
+		bounds := img.Bounds()
+		thumb := image.NewRGBA(image.Rect(0, 0, size, size*bounds.Dy()/bounds.Dx()))
+		xdraw.CatmullRom.Scale(thumb, thumb.Bounds(), img, bounds, xdraw.Src, nil)
+		got := dominantcolor.FindWeight(thumb, 4, dominantcolor.WithResolutionStable())
+		palettetest.AssertPaletteClose(t, got, want, 5)
+	}
+}
+
+func TestClusterer(t *testing.T) {
+	red := color.RGBA{R: 230, G: 20, B: 20, A: 255}
+	blue := color.RGBA{R: 20, G: 20, B: 200, A: 255}
+	samples := []dominantcolor.Sample{
+		{RGBA: red, Weight: 3},
+		{RGBA: color.RGBA{R: 232, G: 22, B: 22, A: 255}, Weight: 1},
+		{RGBA: blue, Weight: 2},
+		{RGBA: color.RGBA{G: 255, A: 255}, Weight: 0},
+	}
+	c := dominantcolor.NewClusterer(samples, 2)
+	steps := 0
+	for ; steps < 10 && !c.Step(); steps++ {
+	}
+	if steps == 10 {
+		t.Fatal("Did not converge")
+	}
+	centroids, weights := c.Centroids(), c.Weights()
+	if len(centroids) != 2 || len(weights) != 2 {
+		t.Fatalf("Unexpected result: %v %v", centroids, weights)
+	}
+	for want, weight := range map[color.RGBA]float64{red: 4, blue: 2} {
+		found := false
+		for i := range centroids {
+			if centroids[i] == want && weights[i] == weight {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Cluster %s with weight %f not found in %v %v", dominantcolor.Hex(want), weight, centroids, weights)
+		}
+	}
+
+	c.SetCentroids([]color.RGBA{blue})
+	c.Step()
+	if w := c.Weights(); len(w) != 1 || w[0] != 6 {
+		t.Errorf("Unexpected weights after SetCentroids: %v", w)
+	}
+}
+
+func TestFindWeight_PinnedColors(t *testing.T) {
+	brand := color.RGBA{R: 240, G: 100, B: 10, A: 255}
+	missing := color.RGBA{G: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{B: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 20, 5), image.NewUniform(color.RGBA{R: 230, G: 96, A: 255}), image.Point{}, draw.Src)
+
+	colors := dominantcolor.FindWeight(img, 3, dominantcolor.WithPinnedColors(brand, missing))
+	want := map[color.RGBA]float64{{B: 200, A: 255}: 0.75, brand: 0.25, missing: 0}
+	if len(colors) != 3 {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	for _, c := range colors {
+		if w, ok := want[c.RGBA]; !ok || math.Abs(c.Weight-w) > 1e-9 {
+			t.Errorf("Unexpected color: %v", c)
+		}
+	}
+}
+
+func TestFindWeight_ExcludeHueRange(t *testing.T) {
+	grass := color.RGBA{R: 60, G: 160, B: 40, A: 255}
+	house := color.RGBA{R: 200, G: 60, B: 50, A: 255}
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), image.NewUniform(grass), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 10, 10), image.NewUniform(house), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(10, 0, 20, 5), image.NewUniform(gray), image.Point{}, draw.Src)
+
+	colors := dominantcolor.FindWeight(img, 3, dominantcolor.WithExcludeHueRange(75, 165))
+	if len(colors) != 2 || colors[0].RGBA != house || colors[1].RGBA != gray {
+		t.Errorf("Unexpected colors excluding greens: %v", colors)
+	}
+	// The range wraps around 0°.
+	colors = dominantcolor.FindWeight(img, 3, dominantcolor.WithExcludeHueRange(330, 30))
+	for _, c := range colors {
+		if c.RGBA == house {
+			t.Errorf("Unexpected colors excluding reds: %v", colors)
+		}
+	}
+}
+
+func TestFind_Salience(t *testing.T) {
+	background := color.RGBA{R: 120, G: 110, B: 100, A: 255}
+	accent := color.RGBA{R: 230, G: 96, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 20, 8), image.NewUniform(accent), image.Point{}, draw.Src)
+
+	if c := dominantcolor.Find(img); c != background {
+		t.Errorf("Unexpected dominant color: %s", dominantcolor.Hex(c))
+	}
+	if c := dominantcolor.Find(img, dominantcolor.WithSalience()); c != accent {
+		t.Errorf("Unexpected salient color: %s", dominantcolor.Hex(c))
+	}
+}
+
+func TestFind_SalienceSaturationBounds(t *testing.T) {
+	background := color.RGBA{R: 120, G: 110, B: 100, A: 255}
+	accent := color.RGBA{R: 230, G: 96, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 40, 16), image.NewUniform(accent), image.Point{}, draw.Src)
+
+	if c := dominantcolor.Find(img, dominantcolor.ForPhotos()); dominantcolor.DeltaE(c, accent) > 5 {
+		t.Errorf("Unexpected salient color: %s", dominantcolor.Hex(c))
+	}
+	c, trace := dominantcolor.FindTrace(img, dominantcolor.ForPhotos(), dominantcolor.WithSaturationBounds(0, 0.15))
+	if dominantcolor.DeltaE(c, background) > 5 {
+		t.Errorf("Salient color ignores saturation bounds: %s", dominantcolor.Hex(c))
+	}
+	for _, candidate := range trace {
+		if dominantcolor.DeltaE(candidate.RGBA, accent) < 5 && candidate.Reason != dominantcolor.ReasonSaturation {
+			t.Errorf("Unexpected reason for the accent: %q", candidate.Reason)
+		}
+	}
+	// If no color is acceptable, the heaviest one is returned.
+	if c := dominantcolor.Find(img, dominantcolor.WithSalience(), dominantcolor.WithSaturationBounds(0.4, 0.6)); c != background {
+		t.Errorf("Unexpected fallback color: %s", dominantcolor.Hex(c))
+	}
+}
+
+func TestFindWeight_HueSectors(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithAlgorithm(dominantcolor.HueSectors))
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	for i := 1; i < len(colors); i++ {
+		if colors[i].Weight > colors[i-1].Weight {
+			t.Errorf("Colors are not sorted by weight: %v", colors)
+		}
+	}
+	c := dominantcolor.Find(img, dominantcolor.WithAlgorithm(dominantcolor.HueSectors))
+	want := dominantcolor.Find(img)
+	if d := distance(c, want); d > 20 {
+		t.Errorf("Found color is not close: %s, want %s, distance %.2f", dominantcolor.Hex(c), dominantcolor.Hex(want), d)
+	}
+}
+
+func TestFindWeight_HueSectorsWrap(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	// Reds at 350° and 10°, and a blue.
+	draw.Draw(img, image.Rect(0, 0, 100, 35), image.NewUniform(color.RGBA{R: 220, G: 30, B: 62, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 35, 100, 70), image.NewUniform(color.RGBA{R: 220, G: 62, B: 30, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 70, 100, 100), image.NewUniform(color.RGBA{R: 30, G: 30, B: 200, A: 255}), image.Point{}, draw.Src)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithAlgorithm(dominantcolor.HueSectors))
+	if len(colors) != 2 || math.Abs(colors[0].Weight-0.7) > 1e-9 {
+		t.Errorf("Reds are not grouped: %v", colors)
+	}
+}
+
+func TestFindWeight_KMedoids(t *testing.T) {
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, image.Rect(0, 0, 20, 40), image.NewUniform(blue), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(20, 0, 40, 40), image.NewUniform(red), image.Point{}, draw.Src)
+	// Dead pixels in the red half.
+	for i := 0; i < 40; i++ {
+		img.Set(20+i%20, i, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithAlgorithm(dominantcolor.KMedoids))
+	if len(colors) != 2 {
+		t.Fatalf("Did not find 2 colors: %v", colors)
+	}
+	got := map[color.RGBA]bool{colors[0].RGBA: true, colors[1].RGBA: true}
+	if !got[red] || !got[blue] {
+		t.Errorf("Medoids are not the image colors: %v", colors)
+	}
+	if math.Abs(colors[0].Weight+colors[1].Weight-1) > 1e-9 {
+		t.Errorf("Weights do not sum to 1: %v", colors)
+	}
+}
+
+func TestFindWeight_Trim(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	// A small white watermark.
+	draw.Draw(img, image.Rect(30, 34, 40, 40), image.NewUniform(color.White), image.Point{}, draw.Src)
+	if colors := dominantcolor.FindWeight(img, 1); colors[0].RGBA == red {
+		t.Fatalf("Watermark does not drag the centroid: %v", colors)
+	}
+	colors := dominantcolor.FindWeight(img, 1, dominantcolor.WithTrim(0.05))
+	if len(colors) != 1 || colors[0].RGBA != red {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	if math.Abs(colors[0].Weight-1) > 1e-9 {
+		t.Errorf("Trimmed pixels are not counted: %v", colors)
+	}
+}
+
+func TestFindFromSeed(t *testing.T) {
+	img := testImage(t)
+	previous := dominantcolor.FindWeight(img, 4)
+	colors := dominantcolor.FindFromSeed(img, previous)
+	if len(colors) != len(previous) {
+		t.Fatalf("Unexpected number of colors: %d", len(colors))
+	}
+	// The same image converges to the same colors.
+	for i := range colors {
+		if colors[i].RGBA != previous[i].RGBA {
+			t.Errorf("Color %d changed from %s to %s", i, dominantcolor.Hex(previous[i].RGBA), dominantcolor.Hex(colors[i].RGBA))
+		}
+	}
+	// A slightly brighter image keeps similar colors in the same order.
+	bright := image.NewRGBA(img.Bounds())
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			bright.SetRGBA(x, y, color.RGBA{R: uint8(math.Min(255, float64(r>>8)+8)), G: uint8(math.Min(255, float64(g>>8)+8)), B: uint8(math.Min(255, float64(b>>8)+8)), A: uint8(a >> 8)})
+		}
+	}
+	for i, c := range dominantcolor.FindFromSeed(bright, previous) {
+		if d := dominantcolor.DeltaE(c.RGBA, previous[i].RGBA); d > 10 {
+			t.Errorf("Color %d jumped from %s to %s", i, dominantcolor.Hex(previous[i].RGBA), dominantcolor.Hex(c.RGBA))
+		}
+	}
+	if colors := dominantcolor.FindFromSeed(img, nil); len(colors) != 4 {
+		t.Errorf("Unexpected number of colors without seeds: %d", len(colors))
+	}
+}
+
+func TestFindWeight_WeightTolerance(t *testing.T) {
+	img := testImage(t)
+	want := dominantcolor.FindWeight(img, 4, dominantcolor.WithSeeding(dominantcolor.KMeansPlusPlus))
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithSeeding(dominantcolor.KMeansPlusPlus), dominantcolor.WithWeightTolerance(0.002))
+	if len(colors) != len(want) {
+		t.Fatalf("Unexpected number of colors: %d", len(colors))
+	}
+	if d := dominantcolor.PaletteDistance(colors, want); d > 3 {
+		t.Errorf("Palettes differ by %f: %v, want %v", d, colors, want)
+	}
+}
+
+func TestCircularHueMean(t *testing.T) {
+	rad := math.Pi / 180
+	for _, tc := range []struct {
+		hues, weights  []float64
+		mean, variance float64
+	}{
+		{[]float64{350, 10}, nil, 0, 1 - math.Cos(10*rad)},
+		{[]float64{350, 10}, []float64{3, 1}, 360 - math.Atan(math.Tan(10*rad)/2)/rad, 1 - math.Hypot(4*math.Cos(10*rad), 2*math.Sin(10*rad))/4},
+		{[]float64{90, 90}, nil, 90, 0},
+		{[]float64{0, 180}, nil, 0, 1},
+		{nil, nil, 0, 1},
+	} {
+		mean, variance := dominantcolor.CircularHueMean(tc.hues, tc.weights)
+		if math.Abs(mean-tc.mean) > 1e-9 {
+			t.Errorf("Unexpected mean of %v: %f, want %f", tc.hues, mean, tc.mean)
+		}
+		if math.Abs(variance-tc.variance) > 1e-9 {
+			t.Errorf("Unexpected variance of %v: %f, want %f", tc.hues, variance, tc.variance)
+		}
+	}
+}
+
+func TestFind_AvoidColors(t *testing.T) {
+	img := testImage(t)
+	c := dominantcolor.Find(img)
+	avoided := dominantcolor.Find(img, dominantcolor.WithAvoidColors([]color.RGBA{c}, 10))
+	if dominantcolor.DeltaE(c, avoided) < 10 {
+		t.Errorf("Avoided color %s is too close to %s", dominantcolor.Hex(avoided), dominantcolor.Hex(c))
+	}
+	// When every color is too close, the farthest one is returned.
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	fallback := dominantcolor.Find(img, dominantcolor.WithAvoidColors([]color.RGBA{white}, 1000))
+	for _, col := range dominantcolor.FindN(img, 4) {
+		if dominantcolor.DeltaE(col, white) > dominantcolor.DeltaE(fallback, white) {
+			t.Errorf("Fallback color %s is not the farthest from white", dominantcolor.Hex(fallback))
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if r := dominantcolor.ContrastRatio(black, white); math.Abs(r-21) > 1e-9 {
+		t.Errorf("Contrast ratio of black and white is %f", r)
+	}
+	if r := dominantcolor.ContrastRatio(white, white); r != 1 {
+		t.Errorf("Contrast ratio of white and white is %f", r)
+	}
+}
+
+func TestFindContrasting(t *testing.T) {
+	img := testImage(t)
+	bg := dominantcolor.Find(img)
+	c, ok := dominantcolor.FindContrasting(img, bg, 3)
+	if !ok {
+		t.Fatal("No contrasting color found")
+	}
+	if r := dominantcolor.ContrastRatio(c, bg); r < 3 {
+		t.Errorf("Contrast ratio of %s against %s is %f", dominantcolor.Hex(c), dominantcolor.Hex(bg), r)
+	}
+	if _, ok := dominantcolor.FindContrasting(img, bg, 22); ok {
+		t.Error("Found color with impossible contrast ratio")
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	red := color.RGBA{R: 230, G: 20, B: 20, A: 255}
+	blue := color.RGBA{R: 20, G: 20, B: 200, A: 255}
+	if c := dominantcolor.Interpolate(red, blue, 0); distance(c, red) > 1.8 {
+		t.Errorf("Interpolate at 0 = %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(red))
+	}
+	if c := dominantcolor.Interpolate(red, blue, 1); distance(c, blue) > 1.8 {
+		t.Errorf("Interpolate at 1 = %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(blue))
+	}
+	// Halfway between black and white is the gray of L* = 50, not the
+	// RGB average.
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if c := dominantcolor.Interpolate(black, white, 0.5); c != (color.RGBA{R: 119, G: 119, B: 119, A: 255}) {
+		t.Errorf("Interpolate at 0.5 = %s, want #777777", dominantcolor.Hex(c))
+	}
+}
+
+func TestSteps(t *testing.T) {
+	palette := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 20, B: 20, A: 255}},
+		{RGBA: color.RGBA{R: 240, G: 220, B: 20, A: 255}},
+		{RGBA: color.RGBA{R: 20, G: 20, B: 200, A: 255}},
+	}
+	steps := dominantcolor.Steps(palette, 5)
+	if len(steps) != 5 {
+		t.Fatalf("Unexpected steps: %v", steps)
+	}
+	for i, j := range map[int]int{0: 0, 2: 1, 4: 2} {
+		if d := distance(steps[i], palette[j].RGBA); d > 1.8 {
+			t.Errorf("Step %d is %s, want %s", i, dominantcolor.Hex(steps[i]), dominantcolor.Hex(palette[j].RGBA))
+		}
+	}
+	if steps := dominantcolor.Steps(nil, 5); steps != nil {
+		t.Errorf("Unexpected steps of empty palette: %v", steps)
+	}
+}
+
+func TestColorSpaces(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if c := dominantcolor.ToOKLab(white); math.Abs(c.L-1) > 1e-3 || math.Abs(c.A) > 1e-3 || math.Abs(c.B) > 1e-3 {
+		t.Errorf("OKLab of white = %+v", c)
+	}
+	red := color.RGBA{R: 255, A: 255}
+	if c := dominantcolor.ToHSLuv(red); math.Abs(c.H-12.177) > 0.01 || math.Abs(c.S-100) > 0.01 || math.Abs(c.L-53.237) > 0.01 {
+		t.Errorf("HSLuv of red = %+v", c)
+	}
+	for _, c := range []color.RGBA{red, white, {R: 12, G: 200, B: 97, A: 255}, {R: 90, G: 20, B: 160, A: 255}} {
+		if got := color.RGBAModel.Convert(dominantcolor.ToOKLab(c)); got != c {
+			t.Errorf("OKLab round trip of %s = %v", dominantcolor.Hex(c), got)
+		}
+		if got := color.RGBAModel.Convert(dominantcolor.ToHSLuv(c)); got != c {
+			t.Errorf("HSLuv round trip of %s = %v", dominantcolor.Hex(c), got)
+		}
+	}
+}
+
+func TestFindWeight_OKLab(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.WithColorSpace(dominantcolor.SpaceOKLab))
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	c := dominantcolor.Find(img, dominantcolor.WithColorSpace(dominantcolor.SpaceOKLab))
+	want := dominantcolor.Find(img)
+	if d := distance(c, want); d > 20 {
+		t.Errorf("Found color is not close: %s, want %s, distance %.2f", dominantcolor.Hex(c), dominantcolor.Hex(want), d)
+	}
+	pinned := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	colors = dominantcolor.FindWeight(img, 4, dominantcolor.WithColorSpace(dominantcolor.SpaceOKLab), dominantcolor.WithPinnedColors(pinned))
+	found := false
+	for _, c := range colors {
+		found = found || c.RGBA == pinned
+	}
+	if !found {
+		t.Errorf("Pinned color is not found: %v", colors)
+	}
+}
+
+func TestFindColor(t *testing.T) {
+	img := testImage(t)
+	want := dominantcolor.Find(img)
+	c, ok := dominantcolor.FindColor(img, color.CMYKModel).(color.CMYK)
+	if !ok {
+		t.Fatalf("Color is not CMYK: %T", c)
+	}
+	if got := color.RGBAModel.Convert(c).(color.RGBA); distance(got, want) > 2 {
+		t.Errorf("CMYK color %v is not close to %s", c, dominantcolor.Hex(want))
+	}
+	colors := dominantcolor.FindNColor(img, 4, color.NRGBA64Model)
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	if _, ok := colors[0].(color.NRGBA64); !ok {
+		t.Errorf("Color is not NRGBA64: %T", colors[0])
+	}
+}
+
+func TestFindCMYK(t *testing.T) {
+	richBlack := color.CMYK{C: 153, M: 102, Y: 102, K: 255}
+	cyan := color.CMYK{C: 255}
+	img := image.NewCMYK(image.Rect(0, 0, 300, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 300; x++ {
+			if x < 200 {
+				img.SetCMYK(x, y, richBlack)
+			} else {
+				img.SetCMYK(x, y, cyan)
+			}
+		}
+	}
+	colors := dominantcolor.FindCMYK(img, 2)
+	if len(colors) != 2 {
+		t.Fatal("Did not find 2 colors. Got:", len(colors))
+	}
+	if colors[0].CMYK != richBlack || colors[1].CMYK != cyan {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	if math.Abs(colors[0].Weight-2.0/3) > 0.01 {
+		t.Errorf("Unexpected weight of rich black: %.2f", colors[0].Weight)
+	}
+}
+
+func TestFindWeight_HSV(t *testing.T) {
+	// Reds on both sides of 0° must form a single cluster.
+	img := image.NewNRGBA(image.Rect(0, 0, 90, 90))
+	for y := 0; y < 90; y++ {
+		for x := 0; x < 90; x++ {
+			c := color.NRGBA{R: 40, G: 40, B: 220, A: 255}
+			switch {
+			case x < 30 && y%2 == 0:
+				c = color.NRGBA{R: 230, B: 40, A: 255}
+			case x < 30:
+				c = color.NRGBA{R: 230, G: 40, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithColorSpace(dominantcolor.SpaceHSV))
+	if len(colors) != 2 {
+		t.Fatal("Did not find 2 colors. Got:", len(colors))
+	}
+	if want := (color.RGBA{R: 40, G: 40, B: 220, A: 255}); distance(colors[0].RGBA, want) > 2 {
+		t.Errorf("Unexpected first color: %s", dominantcolor.Hex(colors[0].RGBA))
+	}
+	if c := colors[1].RGBA; c.R < 200 || c.G > 40 || c.B > 40 {
+		t.Errorf("Unexpected red: %s", dominantcolor.Hex(c))
+	}
+}
+
+func TestFindWeight_YCbCr(t *testing.T) {
+	src := testImage(t)
+	img := image.NewYCbCr(src.Bounds(), image.YCbCrSubsampleRatio444)
+	for y := src.Bounds().Min.Y; y < src.Bounds().Max.Y; y++ {
+		for x := src.Bounds().Min.X; x < src.Bounds().Max.X; x++ {
+			r, g, b, _ := src.At(x, y).RGBA()
+			i := img.YOffset(x, y)
+			img.Y[i], img.Cb[i], img.Cr[i] = color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+		}
+	}
+	c := dominantcolor.Find(img, dominantcolor.WithColorSpace(dominantcolor.SpaceYCbCr))
+	want := dominantcolor.Find(img)
+	if d := distance(c, want); d > 20 {
+		t.Errorf("Found color is not close: %s, want %s, distance %.2f", dominantcolor.Hex(c), dominantcolor.Hex(want), d)
+	}
+}
+
+func TestFindWeight_YCbCrSamples(t *testing.T) {
+	// Half of the pixels are out of the sRGB gamut, so that their mean
+	// differs when they are clamped to sRGB first.
+	img := image.NewYCbCr(image.Rect(0, 0, 8, 8), image.YCbCrSubsampleRatio420)
+	for i := range img.Y {
+		img.Y[i] = 128
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			i := img.COffset(2*x, 2*y)
+			img.Cb[i], img.Cr[i] = 128, 128
+			if x < 2 {
+				img.Cb[i], img.Cr[i] = 255, 255
+			}
+		}
+	}
+	space := dominantcolor.WithColorSpace(dominantcolor.SpaceYCbCr)
+	r, g, b := color.YCbCrToRGB(128, 191, 191)
+	if colors := dominantcolor.FindWeight(img, 1, space); len(colors) != 1 || distance(colors[0].RGBA, color.RGBA{R: r, G: g, B: b, A: 255}) > 3 {
+		t.Errorf("Unexpected colors of samples: %v, want %v", colors, color.RGBA{R: r, G: g, B: b, A: 255})
+	}
+	// Options working on sRGB colors read the pixels in sRGB.
+	want := color.RGBA{R: 191, G: 64, B: 191, A: 255}
+	if colors := dominantcolor.FindWeight(img, 1, space, dominantcolor.WithIgnoreColors(color.RGBA{G: 255, A: 255})); len(colors) != 1 || distance(colors[0].RGBA, want) > 3 {
+		t.Errorf("Unexpected colors of sRGB pixels: %v, want %v", colors, want)
+	}
+}
+
+func TestFindGIF(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	green := color.RGBA{R: 30, G: 200, B: 30, A: 255}
+	frame := func(r image.Rectangle, c color.RGBA) *image.Paletted {
+		return image.NewPaletted(r, color.Palette{c})
+	}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 40, 40), red),
+			frame(image.Rect(0, 0, 40, 40), blue),
+			frame(image.Rect(0, 0, 4, 4), green),
+			frame(image.Rect(0, 0, 40, 40), blue),
+		},
+		Delay:    []int{10, 10, 10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 40, Height: 40},
+	}
+	colors, changes := dominantcolor.FindGIF(g)
+	want := []color.RGBA{red, blue, red, blue}
+	if len(colors) != len(want) {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	for i := range want {
+		if distance(colors[i], want[i]) > 5 {
+			t.Errorf("Color of frame %d is %s, want %s", i, dominantcolor.Hex(colors[i]), dominantcolor.Hex(want[i]))
+		}
+	}
+	if fmt.Sprint(changes) != "[0 1 2 3]" {
+		t.Errorf("Unexpected changes: %v", changes)
+	}
+
+	a := dominantcolor.NewAccumulator()
+	if err := a.AddFrames(dominantcolor.NewGIFSource(g)); err != nil {
+		t.Fatal(err)
+	}
+	if palette := a.Palette(2); len(palette) != 2 {
+		t.Errorf("Unexpected palette of all frames: %v", palette)
+	}
+}
+
+func TestFindGIF_Empty(t *testing.T) {
+	for _, g := range []*gif.GIF{nil, {}} {
+		if colors, changes := dominantcolor.FindGIF(g); len(colors) != 0 || len(changes) != 0 {
+			t.Errorf("Unexpected results for %v: %v %v", g, colors, changes)
+		}
+	}
+	if _, _, err := dominantcolor.NewGIFSource(nil).Next(); err != io.EOF {
+		t.Errorf("Unexpected error for a nil GIF: %v", err)
+	}
+}
+
+func TestFindFrames(t *testing.T) {
+	errDecode := errors.New("decode error")
+	frames := []image.Image{
+		image.NewUniform(color.RGBA{R: 200, G: 30, B: 30, A: 255}),
+		image.NewUniform(color.RGBA{R: 30, G: 30, B: 200, A: 255}),
+	}
+	var i int
+	next := func() (image.Image, time.Duration, error) {
+		if i == len(frames) {
+			return nil, 0, errDecode
+		}
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(img, img.Bounds(), frames[i], image.Point{}, draw.Src)
+		i++
+		return img, 100 * time.Millisecond, nil
+	}
+	colors, changes, err := dominantcolor.FindFrames(dominantcolor.FrameSourceFunc(next))
+	if err != errDecode {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(colors) != 2 || fmt.Sprint(changes) != "[0 1]" {
+		t.Errorf("Unexpected colors %v and changes %v", colors, changes)
+	}
+}
+
+func TestFindIcon(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	uniform := func(size int, c color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return img
+	}
+	icons := []image.Image{uniform(16, blue), uniform(32, red), uniform(16, blue), nil}
+	if c := dominantcolor.FindIcon(icons); c != red {
+		t.Errorf("Color of largest icon is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(red))
+	}
+	icons = append(icons, uniform(24, blue))
+	if c := dominantcolor.FindIconMerged(icons); c != blue {
+		t.Errorf("Merged color is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(blue))
+	}
+	if c := dominantcolor.FindIcon(nil); c != (color.RGBA{}) {
+		t.Errorf("Unexpected color of empty icon set: %v", c)
+	}
+}
+
+func TestFindVector(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	var size int
+	rasterize := dominantcolor.RasterFunc(func(r io.Reader, px int) (image.Image, error) {
+		if _, err := io.ReadAll(r); err != nil {
+			return nil, err
+		}
+		size = px
+		img := image.NewRGBA(image.Rect(0, 0, px, px))
+		draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+		return img, nil
+	})
+	c, err := dominantcolor.FindVector(strings.NewReader("<svg/>"), rasterize, dominantcolor.WithRasterSize(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != red || size != 64 {
+		t.Errorf("Found %s at size %d, want %s at size 64", dominantcolor.Hex(c), size, dominantcolor.Hex(red))
+	}
+	errRaster := errors.New("invalid svg")
+	failing := dominantcolor.RasterFunc(func(io.Reader, int) (image.Image, error) { return nil, errRaster })
+	if _, err := dominantcolor.FindVector(strings.NewReader(""), failing); !errors.Is(err, errRaster) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestFindPages(t *testing.T) {
+	pages := []color.RGBA{{R: 200, G: 30, B: 30, A: 255}, {R: 30, G: 30, B: 200, A: 255}}
+	errRender := errors.New("corrupt page")
+	render := func(page int) (image.Image, error) {
+		if page >= len(pages) {
+			return nil, errRender
+		}
+		img := image.NewRGBA(image.Rect(0, 0, 30, 40))
+		draw.Draw(img, img.Bounds(), image.NewUniform(pages[page]), image.Point{}, draw.Src)
+		return img, nil
+	}
+	colors, err := dominantcolor.FindPages(2, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 2 || colors[0] != pages[0] || colors[1] != pages[1] {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	colors, err = dominantcolor.FindPages(3, render)
+	if !errors.Is(err, errRender) || len(colors) != 2 {
+		t.Errorf("Unexpected colors %v and error %v", colors, err)
+	}
+	for _, n := range []int{0, -1} {
+		if colors, err := dominantcolor.FindPages(n, render); err != nil || colors == nil || len(colors) != 0 {
+			t.Errorf("Unexpected colors %v and error %v for %d pages", colors, err, n)
+		}
+	}
+}
+
+func TestForScreenshots(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	blue := color.RGBA{R: 26, G: 115, B: 232, A: 255}
+	gray := color.RGBA{R: 241, G: 243, B: 244, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 600, 60), image.NewUniform(gray), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(40, 100, 300, 220), image.NewUniform(blue), image.Point{}, draw.Src)
+	// Text
+	for y := 250; y < 350; y += 4 {
+		draw.Draw(img, image.Rect(40, y, 560, y+1), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.ForScreenshots())
+	if len(colors) != 2 || colors[0].RGBA != gray || colors[1].RGBA != blue {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+}
+
+func TestFindWeight_KMeansPlusPlus(t *testing.T) {
+	want := []color.RGBA{
+		{R: 200, G: 30, B: 30, A: 255},
+		{R: 30, G: 200, B: 30, A: 255},
+		{R: 30, G: 30, B: 200, A: 255},
+		{R: 220, G: 220, B: 30, A: 255},
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for i, c := range want {
+		draw.Draw(img, image.Rect(0, i*25, 100, 100), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	colors := dominantcolor.FindN(img, 4, dominantcolor.WithSeeding(dominantcolor.KMeansPlusPlus))
+	if len(colors) != 4 {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	for _, w := range want {
+		found := false
+		for _, c := range colors {
+			found = found || c == w
+		}
+		if !found {
+			t.Errorf("Color %s is not found: %v", dominantcolor.Hex(w), colors)
+		}
+	}
+}
+
+func TestFindWeight_Blur(t *testing.T) {
+	// Fine checkerboard of red and yellow looks orange.
+	img := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 60; x++ {
+			c := color.RGBA{R: 240, A: 255}
+			if (x+y)%2 == 0 {
+				c.G = 240
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	colors := dominantcolor.FindN(img, 2, dominantcolor.WithBlur(1))
+	if len(colors) == 0 {
+		t.Fatal("No colors found")
+	}
+	for _, c := range colors {
+		if c.R != 240 || c.G < 90 || c.G > 150 {
+			t.Errorf("Blurred color is %s, want orange", dominantcolor.Hex(c))
+		}
+	}
+}
+
+func TestForPhotos(t *testing.T) {
+	img := testImage(t)
+	colors := dominantcolor.FindWeight(img, 4, dominantcolor.ForPhotos())
+	if len(colors) != 4 {
+		t.Fatal("Did not find 4 colors. Got:", len(colors))
+	}
+	c := dominantcolor.Find(img, dominantcolor.ForPhotos())
+	if want := dominantcolor.Find(img, dominantcolor.WithSalience()); distance(c, want) > 20 {
+		t.Errorf("Found color is not close: %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(want))
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var config dominantcolor.Config
+	err := json.Unmarshal([]byte(`{
+		"algorithm": "hue-sectors",
+		"color_space": "oklab",
+		"edge_weight": 0,
+		"pinned_colors": ["#1A73E8"],
+		"exclude_hue_ranges": [[330, 30]]
+	}`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Algorithm != dominantcolor.HueSectors || config.ColorSpace != dominantcolor.SpaceOKLab || config.EdgeWeight == nil {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 5 {
+		t.Errorf("Unexpected number of options: %d", len(opts))
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"algorithm":"hue-sectors"`) {
+		t.Errorf("Unexpected JSON: %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"seeding": "best"}`), &config); err == nil {
+		t.Error("Unknown seeding is accepted")
+	}
+	config = dominantcolor.Config{AvoidColors: []string{"#12345"}}
+	if _, err := config.Options(); err == nil {
+		t.Error("Invalid color is accepted")
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	c, err := dominantcolor.ParseHex("#CB5A27")
+	if err != nil || c != (color.RGBA{R: 0xCB, G: 0x5A, B: 0x27, A: 0xFF}) {
+		t.Errorf("ParseHex = %v, %v", c, err)
+	}
+	for _, s := range []string{"", "#CB5A2", "CB5A27FF", "#GG0000"} {
+		if _, err := dominantcolor.ParseHex(s); err == nil {
+			t.Errorf("ParseHex(%q) succeeded", s)
+		}
+	}
+}
+
+func TestFindDistributions(t *testing.T) {
+	gray := func(v uint8) color.RGBA { return color.RGBA{R: v, G: v, B: v, A: 255} }
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, image.Rect(0, 0, 100, 60), image.NewUniform(gray(100)), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 60, 100, 90), image.NewUniform(gray(150)), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 90, 100, 100), image.NewUniform(gray(200)), image.Point{}, draw.Src)
+	distributions := dominantcolor.FindDistributions(img, 1)
+	if len(distributions) != 1 {
+		t.Fatalf("Unexpected distributions: %v", distributions)
+	}
+	d := distributions[0]
+	if d.RGBA != gray(125) || d.P25 != gray(100) || d.P50 != gray(100) || d.P75 != gray(150) || d.Medoid != gray(100) {
+		t.Errorf("Unexpected distribution: %+v", d)
+	}
+	if d := dominantcolor.FindDistributions(nil, 1); len(d) != 0 {
+		t.Errorf("Unexpected distributions of nil image: %v", d)
+	}
+}
+
+func TestFindDistributions_Vivid(t *testing.T) {
+	muted := color.RGBA{R: 150, G: 90, B: 90, A: 255}
+	vivid := color.RGBA{R: 220, G: 40, B: 40, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, image.Rect(0, 0, 100, 80), image.NewUniform(muted), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 80, 100, 100), image.NewUniform(vivid), image.Point{}, draw.Src)
+	distributions := dominantcolor.FindDistributions(img, 1)
+	if len(distributions) != 1 {
+		t.Fatalf("Unexpected distributions: %v", distributions)
+	}
+	if d := distributions[0]; d.Vivid != vivid {
+		t.Errorf("Unexpected vivid color %v of %v", d.Vivid, d.RGBA)
+	}
+}
+
+func TestFindWeight_PresentColors(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	yellow := color.RGBA{R: 220, G: 220, B: 30, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 100, 40), image.NewUniform(yellow), image.Point{}, draw.Src)
+	colors := dominantcolor.FindWeight(img, 1, dominantcolor.WithPresentColors())
+	if len(colors) != 1 || colors[0].RGBA != red || colors[0].Weight != 1 {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+}
+
+func TestFindWeight_PresentColorsSpatial(t *testing.T) {
+	// Red on both sides of the image belongs to two spatial clusters.
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 90, 30))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(30, 0, 60, 30), image.NewUniform(blue), image.Point{}, draw.Src)
+	colors := dominantcolor.FindWeight(img, 3, dominantcolor.WithSpatialWeight(10), dominantcolor.WithPresentColors())
+	for _, c := range colors {
+		if c.RGBA != red && c.RGBA != blue {
+			t.Errorf("Unexpected color: %v", c)
+		}
+	}
+}
+
+func TestFind_SaturationBounds(t *testing.T) {
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(gray), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 100, 40), image.NewUniform(red), image.Point{}, draw.Src)
+	if c := dominantcolor.Find(img); c != gray {
+		t.Errorf("Find = %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(gray))
+	}
+	if c := dominantcolor.Find(img, dominantcolor.WithSaturationBounds(0.4, 1)); c != red {
+		t.Errorf("Colorful color is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(red))
+	}
+	draw.Draw(img, image.Rect(0, 0, 100, 70), image.NewUniform(red), image.Point{}, draw.Src)
+	if c := dominantcolor.Find(img, dominantcolor.WithSaturationBounds(0, 0.15)); c != gray {
+		t.Errorf("Neutral color is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(gray))
+	}
+}
+
+func TestFindTrace(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 100, 30), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 30, 100, 50), image.NewUniform(blue), image.Point{}, draw.Src)
+	c, trace := dominantcolor.FindTrace(img)
+	if c != red || len(trace) != 3 {
+		t.Fatalf("Unexpected pick %s and trace %+v", dominantcolor.Hex(c), trace)
+	}
+	want := []struct {
+		color  color.RGBA
+		picked bool
+		reason string
+	}{
+		{white, false, dominantcolor.ReasonTooBright},
+		{red, true, ""},
+		{blue, false, dominantcolor.ReasonOutranked},
+	}
+	for i, w := range want {
+		if got := trace[i]; got.RGBA != w.color || got.Picked != w.picked || got.Reason != w.reason {
+			t.Errorf("Candidate %d is %+v, want %s picked=%t reason=%q", i, got, dominantcolor.Hex(w.color), w.picked, w.reason)
+		}
+	}
+	_, trace = dominantcolor.FindTrace(img, dominantcolor.WithAvoidColors([]color.RGBA{red}, 10))
+	if !trace[2].Picked || trace[1].Reason != dominantcolor.ReasonAvoided {
+		t.Errorf("Unexpected trace with avoided colors: %+v", trace)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 90, 90))
+	draw.Draw(img, image.Rect(0, 0, 30, 90), image.NewUniform(color.NRGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(30, 0, 50, 90), image.NewUniform(color.NRGBA{B: 200, A: 255}), image.Point{}, draw.Src)
+	colors := dominantcolor.FindWeightNormalized(img, 4)
+	var sum float64
+	for _, c := range colors {
+		sum += c.Weight
+	}
+	if len(colors) != 2 || math.Abs(sum-1) > 1e-9 || math.Abs(colors[0].Weight-0.6) > 1e-9 {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	percentages := dominantcolor.Percentages([]dominantcolor.Color{{Weight: 1}, {Weight: 1}, {Weight: 1}})
+	if fmt.Sprint(percentages) != "[34 33 33]" {
+		t.Errorf("Unexpected percentages: %v", percentages)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	colors := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 96, B: 10, A: 255}, Weight: 0.5},
+		{RGBA: color.RGBA{R: 240, G: 110, B: 20, A: 255}, Weight: 0.2},
+		{RGBA: color.RGBA{R: 20, G: 20, B: 200, A: 255}, Weight: 0.3},
+	}
+	orange := color.RGBA{R: 0xE6, G: 0x60, B: 0x0A, A: 255}
+	if c := dominantcolor.Coverage(colors, orange, 20); math.Abs(c-0.7) > 1e-9 {
+		t.Errorf("Unexpected coverage: %f", c)
+	}
+	if c := dominantcolor.Coverage(colors, orange, 1); c != 0.5 {
+		t.Errorf("Unexpected coverage with low tolerance: %f", c)
+	}
+}
+
+func TestFindCovering(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for i, c := range []color.RGBA{
+		{R: 200, G: 30, B: 30, A: 255},
+		{R: 30, G: 200, B: 30, A: 255},
+		{R: 30, G: 30, B: 200, A: 255},
+		{R: 200, G: 200, B: 30, A: 255},
+	} {
+		// Stripes of 50, 30, 15 and 5 rows.
+		y0 := []int{0, 50, 80, 95}[i]
+		y1 := []int{50, 80, 95, 100}[i]
+		draw.Draw(img, image.Rect(0, y0, 100, y1), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	for _, tc := range []struct {
+		coverage float64
+		n        int
+	}{{0.5, 1}, {0.8, 2}, {0.81, 3}, {1, 4}} {
+		if colors := dominantcolor.FindCovering(img, tc.coverage); len(colors) != tc.n {
+			t.Errorf("FindCovering(%v) = %v, want %d colors", tc.coverage, colors, tc.n)
+		}
+	}
+}
+
+func TestCumulativeWeights(t *testing.T) {
+	colors := []dominantcolor.Color{{Weight: 0.5}, {Weight: 0.25}, {Weight: 0.125}}
+	want := []float64{0.5, 0.75, 0.875}
+	got := dominantcolor.CumulativeWeights(colors)
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected cumulative weights: %v", got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Unexpected cumulative weights: %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestFindCounts(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1000, 800))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.NRGBA{B: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 1000, 200), image.NewUniform(color.NRGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+	counts := dominantcolor.FindCounts(img, 2)
+	if len(counts) != 2 || counts[0].Pixels+counts[1].Pixels != 800000 || math.Abs(float64(counts[1].Pixels)-200000) > 2000 {
+		t.Errorf("Unexpected counts: %v", counts)
+	}
+	if counts := dominantcolor.FindCounts(nil, 2); len(counts) != 0 {
+		t.Errorf("Unexpected counts of nil image: %v", counts)
+	}
+}
+
+func TestFindWeight_StratifiedSeeding(t *testing.T) {
+	red := color.RGBA{R: 220, G: 20, B: 20, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			// Noisy gray background
+			v := uint8(100 + (x*7+y*13)%40)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	draw.Draw(img, image.Rect(188, 188, 200, 200), image.NewUniform(red), image.Point{}, draw.Src)
+	colors := dominantcolor.FindN(img, 4, dominantcolor.WithSeeding(dominantcolor.StratifiedSeeding))
+	found := false
+	for _, c := range colors {
+		found = found || distance(c, red) < 10
+	}
+	if !found {
+		t.Errorf("Subject in the corner is missed: %v", colors)
+	}
+}
+
+func TestFindWeight_SampleSize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 0, 200, 200), image.NewUniform(color.RGBA{B: 200, A: 255}), image.Point{}, draw.Src)
+	// Blue pixels count for a third of red pixels.
+	masker := dominantcolor.MaskerFunc(func(img image.Image) *image.Alpha {
+		mask := image.NewAlpha(img.Bounds())
+		draw.Draw(mask, mask.Bounds(), image.NewUniform(color.Alpha{255}), image.Point{}, draw.Src)
+		draw.Draw(mask, image.Rect(100, 0, 200, 200), image.NewUniform(color.Alpha{85}), image.Point{}, draw.Src)
+		return mask
+	})
+	want := dominantcolor.FindWeight(img, 2, dominantcolor.WithMasker(masker))
+	colors := dominantcolor.FindWeight(img, 2, dominantcolor.WithMasker(masker), dominantcolor.WithSampleSize(2000))
+	if len(colors) != 2 || len(want) != 2 {
+		t.Fatalf("Unexpected colors: %v, want %v", colors, want)
+	}
+	for i := range want {
+		if colors[i].RGBA != want[i].RGBA || math.Abs(colors[i].Weight-want[i].Weight) > 0.03 {
+			t.Errorf("Sampled color %v, want %v", colors[i], want[i])
+		}
+	}
+}
+
+func TestWorkingImage(t *testing.T) {
+	img := largeTestImage(t)
+	thumb := dominantcolor.WorkingImage(img)
+	if b := thumb.Bounds(); b.Dx() > 256 || b.Dy() > 256 {
+		t.Errorf("Working image is too large: %v", b)
+	}
+	want := dominantcolor.FindWeight(img, 4)
+	colors := dominantcolor.FindWeight(thumb, 4, dominantcolor.WithoutResize())
+	if fmt.Sprint(colors) != fmt.Sprint(want) {
+		t.Errorf("Colors of working image %v, want %v", colors, want)
+	}
+	if stable := dominantcolor.WorkingImage(img, dominantcolor.WithResolutionStable()); stable.Bounds().Dx() > 64 {
+		t.Errorf("Working image is too large: %v", stable.Bounds())
+	}
+}
+
+func TestDecodeAndFind(t *testing.T) {
+	data, err := os.ReadFile("firefox.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dominantcolor.DecodeAndFind(bytes.NewReader(data), dominantcolor.DecodeLimits{MaxPixels: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dominantcolor.Find(testImage(t)); c != want {
+		t.Errorf("Found %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(want))
+	}
+	_, err = dominantcolor.DecodeAndFind(bytes.NewReader(data), dominantcolor.DecodeLimits{MaxWidth: 10})
+	var sizeErr *dominantcolor.ImageSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := dominantcolor.DecodeAndFind(strings.NewReader("not an image"), dominantcolor.DecodeLimits{}); err == nil {
+		t.Error("Invalid image is decoded")
+	}
+}
+
+func TestFindQuadrants(t *testing.T) {
+	colors := []color.RGBA{
+		dominantcolor.TopLeft:     {R: 200, G: 30, B: 30, A: 255},
+		dominantcolor.TopRight:    {R: 30, G: 200, B: 30, A: 255},
+		dominantcolor.BottomLeft:  {R: 30, G: 30, B: 200, A: 255},
+		dominantcolor.BottomRight: {R: 220, G: 220, B: 30, A: 255},
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	draw.Draw(img, image.Rect(0, 0, 200, 150), image.NewUniform(colors[0]), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 0, 400, 150), image.NewUniform(colors[1]), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 150, 200, 300), image.NewUniform(colors[2]), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 150, 400, 300), image.NewUniform(colors[3]), image.Point{}, draw.Src)
+	palettes := dominantcolor.FindQuadrants(img, 2)
+	for q, palette := range palettes {
+		if len(palette) == 0 || palette[0].RGBA != colors[q] || palette[0].Weight < 0.98 {
+			t.Errorf("Unexpected palette of quadrant %d: %v", q, palette)
+		}
+	}
+	for _, palette := range dominantcolor.FindQuadrants(nil, 2) {
+		if len(palette) != 0 {
+			t.Errorf("Unexpected palette of nil image: %v", palette)
+		}
+	}
+}
+
+func TestSuggestTextPlacement(t *testing.T) {
+	// Noisy image with a uniform dark sky in the top right.
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 37 % 256), G: uint8(y * 59 % 256), B: uint8((x + y) * 11 % 256), A: 255})
+		}
+	}
+	sky := color.RGBA{R: 20, G: 30, B: 80, A: 255}
+	draw.Draw(img, image.Rect(100, 0, 200, 100), image.NewUniform(sky), image.Point{}, draw.Src)
+	p, ok := dominantcolor.SuggestTextPlacement(img)
+	if !ok {
+		t.Fatal("No placement suggested")
+	}
+	if p.Quadrant != dominantcolor.TopRight || p.Region != image.Rect(100, 0, 200, 100) || p.Background != sky {
+		t.Errorf("Unexpected placement: %+v", p)
+	}
+	if white := (color.RGBA{R: 255, G: 255, B: 255, A: 255}); p.Text != white || p.Contrast < 4.5 {
+		t.Errorf("Unexpected text color %s with contrast %.2f", dominantcolor.Hex(p.Text), p.Contrast)
+	}
+	if _, ok := dominantcolor.SuggestTextPlacement(nil); ok {
+		t.Error("Placement suggested for nil image")
+	}
+}
+
+func TestEdgeColors(t *testing.T) {
+	top := color.RGBA{R: 250, G: 250, B: 250, A: 255}
+	bottom := color.RGBA{R: 10, G: 10, B: 10, A: 255}
+	left := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	right := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	draw.Draw(img, image.Rect(0, 0, 200, 300), image.NewUniform(left), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 0, 400, 300), image.NewUniform(right), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 400, 30), image.NewUniform(top), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 270, 400, 300), image.NewUniform(bottom), image.Point{}, draw.Src)
+	want := dominantcolor.Edges{Top: top, Bottom: bottom, Left: left, Right: right}
+	if edges := dominantcolor.EdgeColors(img); edges != want {
+		t.Errorf("EdgeColors = %+v, want %+v", edges, want)
+	}
+}
+
+func TestFindBorderColor(t *testing.T) {
+	fill := color.RGBA{R: 240, G: 170, B: 110, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	c, ok := dominantcolor.FindBorderColor(img, white, 3)
+	if !ok {
+		t.Fatal("No border color found")
+	}
+	if r := dominantcolor.ContrastRatio(c, fill); r < 3 {
+		t.Errorf("Unexpected contrast with the image: %f", r)
+	}
+	if r := dominantcolor.ContrastRatio(c, white); r < 3 {
+		t.Errorf("Unexpected contrast with the page: %f", r)
+	}
+	if c.R <= c.G || c.G <= c.B {
+		t.Errorf("Unexpected hue of border color %v", c)
+	}
+	// Mid gray cannot contrast 5:1 with both black and white.
+	black := color.RGBA{A: 255}
+	draw.Draw(img, img.Bounds(), image.NewUniform(black), image.Point{}, draw.Src)
+	if c, ok := dominantcolor.FindBorderColor(img, white, 5); ok {
+		t.Errorf("Unexpected border color %v", c)
+	}
+}
+
+func TestDetectLetterbox(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 150))
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 200; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 37 % 256), G: uint8(y * 59 % 256), B: uint8((x + y) * 11 % 256), A: 255})
+		}
+	}
+	if l, ok := dominantcolor.DetectLetterbox(img); ok {
+		t.Errorf("Letterbox detected in image without bars: %+v", l)
+	}
+	// Center the image in a wider frame with black bars.
+	bars := color.RGBA{R: 3, G: 2, B: 4, A: 255}
+	frame := image.NewRGBA(image.Rect(0, 0, 280, 150))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(bars), image.Point{}, draw.Src)
+	content := img.Bounds().Add(image.Pt(40, 0))
+	draw.Draw(frame, content, img, image.Point{}, draw.Src)
+	l, ok := dominantcolor.DetectLetterbox(frame)
+	if !ok || l.Left != 40 || l.Right != 40 || l.Top != 0 || l.Bottom != 0 || l.Color != bars {
+		t.Fatalf("Unexpected letterbox: %+v, %t", l, ok)
+	}
+	if c := l.Content(frame.Bounds()); c != content {
+		t.Errorf("Unexpected content bounds: %v", c)
+	}
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(bars), image.Point{}, draw.Src)
+	for _, uniform := range []image.Image{frame, image.NewUniform(bars)} {
+		if l, ok := dominantcolor.DetectLetterbox(uniform); ok {
+			t.Errorf("Letterbox detected in uniform image: %+v", l)
+		}
+	}
+}
+
+func TestDetectLetterbox_Tall(t *testing.T) {
+	// A line of content inside the top bar is between the rows sampled
+	// with a stride.
+	bars := color.RGBA{R: 3, G: 2, B: 4, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 2000))
+	for y := 0; y < 2000; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{R: uint8(x * 37 % 256), G: uint8(y * 59 % 256), B: 200, A: 255}
+			if y < 100 && y != 50 {
+				c = bars
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	if l, ok := dominantcolor.DetectLetterbox(img); !ok || l.Top != 50 {
+		t.Errorf("Unexpected letterbox: %+v, %t", l, ok)
+	}
+}
+
+func TestCollage(t *testing.T) {
+	uniform := func(c color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return img
+	}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	green := color.RGBA{R: 30, G: 200, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	var nilImage *image.RGBA
+	images := []image.Image{uniform(blue), nil, uniform(red), nilImage, image.NewRGBA(image.Rectangle{}), uniform(green)}
+	sheet := dominantcolor.Collage(images, 2, 10)
+	if sheet.Bounds() != image.Rect(0, 0, 20, 20) {
+		t.Fatalf("Unexpected bounds: %v", sheet.Bounds())
+	}
+	for _, tc := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{5, 5, red},
+		{15, 5, green},
+		{5, 15, blue},
+		{15, 15, color.RGBA{}},
+	} {
+		if got := sheet.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("Unexpected color at (%d, %d): %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestPlaceholderPNG(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	data, err := dominantcolor.PlaceholderPNG([]dominantcolor.Color{{RGBA: red, Weight: 0.6}, {RGBA: blue, Weight: 0.4}}, 40, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 40, 20) {
+		t.Fatalf("Unexpected bounds: %v", img.Bounds())
+	}
+	at := func(x, y int) color.RGBA { return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA) }
+	if got := at(0, 0); got != red {
+		t.Errorf("Unexpected top left color: %v", got)
+	}
+	if got := at(39, 19); got != blue {
+		t.Errorf("Unexpected bottom right color: %v", got)
+	}
+	if mid := at(20, 10); mid == red || mid == blue {
+		t.Errorf("Unexpected color in the middle: %v", mid)
+	}
+	if _, err := dominantcolor.PlaceholderPNG(nil, 40, 20); err != dominantcolor.ErrEmptyPalette {
+		t.Errorf("Unexpected error for empty palette: %v", err)
+	}
+}
+
+func TestEstimateDistinctColors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	palette := make([]color.RGBA, 1000)
+	for i := range palette {
+		palette[i] = color.RGBA{R: uint8(i), G: uint8(i >> 8), B: 100, A: 255}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 1024, 1024))
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := palette[rnd.Intn(len(palette))]
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	if n := dominantcolor.EstimateDistinctColors(img); n != len(palette) {
+		t.Errorf("Unexpected estimate for frequent colors: %d", n)
+	}
+	// Every pixel has a different color.
+	for i := 0; i < len(img.Pix); i += 4 {
+		j := i / 4
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = uint8(j), uint8(j>>8), uint8(j>>16)
+	}
+	if n := dominantcolor.EstimateDistinctColors(img); n != 1024*1024 {
+		t.Errorf("Unexpected estimate for unique colors: %d", n)
+	}
+	small := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	small.Pix = []uint8{1, 2, 3, 255, 1, 2, 3, 255, 4, 5, 6, 255, 7, 8, 9, 255}
+	if n := dominantcolor.EstimateDistinctColors(small); n != 3 {
+		t.Errorf("Unexpected count for small image: %d", n)
+	}
+}
+
+func TestFindClusters(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, image.Rect(0, 0, 100, 30), image.NewUniform(color.RGBA{R: 190, G: 30, B: 30, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 30, 100, 60), image.NewUniform(color.RGBA{R: 210, G: 30, B: 30, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 60, 100, 100), image.NewUniform(color.RGBA{R: 30, G: 30, B: 200, A: 255}), image.Point{}, draw.Src)
+	clusters := dominantcolor.FindClusters(img, 2)
+	want := []dominantcolor.Cluster{
+		{Centroid: color.RGBA{R: 200, G: 30, B: 30, A: 255}, Weight: 0.6, Count: 6000, Variance: 100},
+		{Centroid: color.RGBA{R: 30, G: 30, B: 200, A: 255}, Weight: 0.4, Count: 4000, Variance: 0},
+	}
+	if len(clusters) != len(want) {
+		t.Fatalf("Unexpected clusters: %+v", clusters)
+	}
+	for i, c := range clusters {
+		if c.Centroid != want[i].Centroid || c.Count != want[i].Count ||
+			math.Abs(c.Weight-want[i].Weight) > 1e-9 || math.Abs(c.Variance-want[i].Variance) > 1e-9 {
+			t.Errorf("Unexpected cluster %d: %+v, want %+v", i, c, want[i])
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	frame := func(colors ...color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		for i, c := range colors {
+			// Earlier colors cover more of the frame.
+			draw.Draw(img, image.Rect(0, 0, 100, 100-i*30), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+		return img
+	}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	green := color.RGBA{R: 30, G: 180, B: 30, A: 255}
+	ids := func(colors []dominantcolor.TrackedColor) map[color.RGBA]int {
+		m := make(map[color.RGBA]int)
+		for _, c := range colors {
+			m[c.RGBA] = c.ID
+		}
+		return m
+	}
+	tracker := dominantcolor.NewTracker(2)
+	first := ids(tracker.Update(frame(red, blue)))
+	// Colors drift and swap places.
+	redder := color.RGBA{R: 215, G: 25, B: 25, A: 255}
+	second := ids(tracker.Update(frame(blue, redder)))
+	if second[blue] != first[blue] || second[redder] != first[red] {
+		t.Errorf("Unexpected IDs %v after %v", second, first)
+	}
+	third := ids(tracker.Update(frame(green, blue)))
+	if third[blue] != first[blue] || third[green] == first[red] || third[green] == first[blue] {
+		t.Errorf("Unexpected IDs %v after %v", third, first)
+	}
+	// Red comes back after missing a frame.
+	if fourth := ids(tracker.Update(frame(red, green))); fourth[red] != first[red] || fourth[green] != third[green] {
+		t.Errorf("Unexpected IDs %v after %v", fourth, third)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	green := color.RGBA{R: 30, G: 180, B: 30, A: 255}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	frames := []color.RGBA{green, {R: 35, G: 178, B: 32, A: 255}, red, red, green}
+	i := 0
+	src := dominantcolor.FrameSourceFunc(func() (image.Image, time.Duration, error) {
+		if i == len(frames) {
+			return nil, 0, io.EOF
+		}
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(img, img.Bounds(), image.NewUniform(frames[i]), image.Point{}, draw.Src)
+		i++
+		return img, time.Second, nil
+	})
+	w := dominantcolor.NewWatcher([]dominantcolor.Color{{RGBA: green, Weight: 1}}, 10)
+	var alerts []dominantcolor.Alert
+	if err := w.Watch(src, func(a dominantcolor.Alert) { alerts = append(alerts, a) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Unexpected alerts: %+v", alerts)
+	}
+	if a := alerts[0]; a.Frame != 2 || !a.Deviating || a.Colors[0].RGBA != red {
+		t.Errorf("Unexpected first alert: %+v", a)
+	}
+	if a := alerts[1]; a.Frame != 4 || a.Deviating || a.Distance != 0 {
+		t.Errorf("Unexpected second alert: %+v", a)
+	}
+}
+
+// blockingImage is a uniform image whose pixels cannot be read until
+// release is closed. started is closed when the first pixel is read.
+type blockingImage struct {
+	*image.Uniform
+	once             *sync.Once
+	started, release chan struct{}
+}
+
+func (b blockingImage) Bounds() image.Rectangle { return image.Rect(0, 0, 10, 10) }
+
+func (b blockingImage) At(x, y int) color.Color {
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return b.Uniform.At(x, y)
+}
+
+func TestAnalyzer(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := blockingImage{
+		Uniform: image.NewUniform(red),
+		once:    new(sync.Once),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := dominantcolor.NewAnalyzer(1, 1, 20*time.Millisecond)
+	done := make(chan error)
+	go func() {
+		c, err := a.Find(context.Background(), img)
+		if err == nil && c != red {
+			err = fmt.Errorf("unexpected color %v", c)
+		}
+		done <- err
+	}()
+	<-img.started
+	// The second request waits for the first until the timeout expires.
+	if _, err := a.Find(context.Background(), img); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error of waiting request: %v", err)
+	}
+	// The running request was abandoned but keeps its slot.
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error of running request: %v", err)
+	}
+	// Without timeout, requests wait until there are too many of them.
+	a = dominantcolor.NewAnalyzer(1, 1, 0)
+	img.once, img.started = new(sync.Once), make(chan struct{})
+	go a.Find(context.Background(), img)
+	<-img.started
+	// Of three more requests, one waits and the others are rejected.
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := a.Find(ctx, img)
+			done <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-done; !errors.Is(err, dominantcolor.ErrQueueFull) {
+			t.Errorf("Unexpected error of request over the queue limit: %v", err)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error of canceled request: %v", err)
+	}
+	close(img.release)
+}
+
+func TestWithSpans(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 30, B: 30, A: 255}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	var phases []string
+	open := 0
+	spans := dominantcolor.WithSpans(func(p dominantcolor.Phase) func() {
+		phases = append(phases, string(p))
+		open++
+		return func() { open-- }
+	})
+	if _, err := dominantcolor.DecodeAndFind(&buf, dominantcolor.DecodeLimits{}, spans); err != nil {
+		t.Fatal(err)
+	}
+	if want := "decode resize pixels init iterations selection"; strings.Join(phases, " ") != want {
+		t.Errorf("Unexpected phases %q, want %q", phases, want)
+	}
+	if open != 0 {
+		t.Errorf("%d spans not ended", open)
+	}
+}
+
+func TestWithResizer(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	var sizes []image.Point
+	resizer := dominantcolor.ResizerFunc(func(img image.Image, w, h int) image.Image {
+		sizes = append(sizes, image.Pt(w, h))
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+		return dst
+	})
+	if c := dominantcolor.Find(img, dominantcolor.WithResizer(resizer)); c != red {
+		t.Errorf("Unexpected color %v", c)
+	}
+	if len(sizes) != 1 || sizes[0] != image.Pt(256, 128) {
+		t.Errorf("Unexpected resizes: %v", sizes)
+	}
+	// Small images are not resized.
+	dominantcolor.Find(img.SubImage(image.Rect(0, 0, 100, 100)), dominantcolor.WithResizer(resizer))
+	if len(sizes) != 1 {
+		t.Errorf("Unexpected resizes: %v", sizes)
+	}
+}
+
+// rowSource is a PixelSource of an image given as rows of colors.
+type rowSource [][]color.NRGBA
+
+func (s rowSource) Size() (int, int) { return len(s[0]), len(s) }
+
+func (s rowSource) Pixels(fn func(c color.NRGBA, weight float64)) {
+	for _, row := range s {
+		for _, c := range row {
+			fn(c, 1)
+		}
+	}
+}
+
+func TestFindPixels(t *testing.T) {
+	red := color.NRGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.NRGBA{R: 30, G: 30, B: 200, A: 255}
+	transparent := color.NRGBA{R: 30, G: 200, B: 30}
+	src := rowSource{
+		{red, red, red, blue},
+		{red, red, blue, blue},
+		{transparent, transparent, transparent, transparent},
+	}
+	colors := dominantcolor.FindPixelsWeight(src, 2)
+	want := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 200, G: 30, B: 30, A: 255}, Weight: 5.0 / 12},
+		{RGBA: color.RGBA{R: 30, G: 30, B: 200, A: 255}, Weight: 3.0 / 12},
+	}
+	if len(colors) != len(want) {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	for i, c := range colors {
+		if c.RGBA != want[i].RGBA || math.Abs(c.Weight-want[i].Weight) > 1e-9 {
+			t.Errorf("Unexpected color %d: %v, want %v", i, c, want[i])
+		}
+	}
+	if c := dominantcolor.FindPixels(src); c != want[0].RGBA {
+		t.Errorf("Unexpected color: %v", c)
+	}
+	// The last row is missing a pixel.
+	src[2] = src[2][:3]
+	if colors := dominantcolor.FindPixelsWeight(src, 2); len(colors) != 0 {
+		t.Errorf("Unexpected colors of incomplete source: %v", colors)
+	}
+}
+
+// weightedSource is a PixelSource reporting the given size and providing
+// its pixels with their weights.
+type weightedSource struct {
+	width, height int
+	pixels        []color.NRGBA
+	weights       []float64
+}
+
+func (s weightedSource) Size() (int, int) { return s.width, s.height }
+
+func (s weightedSource) Pixels(fn func(c color.NRGBA, weight float64)) {
+	for i, c := range s.pixels {
+		fn(c, s.weights[i])
+	}
+}
+
+func TestFindPixels_Options(t *testing.T) {
+	red := color.NRGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.NRGBA{R: 30, G: 30, B: 200, A: 255}
+	green := color.NRGBA{R: 30, G: 200, B: 30, A: 255}
+	src := weightedSource{
+		width:   4,
+		height:  1,
+		pixels:  []color.NRGBA{red, blue, green, green},
+		weights: []float64{1, 1, -5, math.NaN()},
+	}
+	// Green pixels have invalid weights and are ignored.
+	colors := dominantcolor.FindPixelsWeight(src, 3)
+	if len(colors) != 2 {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+	for _, c := range colors {
+		if c.RGBA.G == 200 || math.IsNaN(c.Weight) || c.Weight != 0.25 {
+			t.Errorf("Unexpected color: %v", c)
+		}
+	}
+	// Regions select pixels of the source as of an image.
+	colors = dominantcolor.FindPixelsWeight(src, 3, dominantcolor.WithIncludeRegions(image.Rect(1, 0, 2, 1)))
+	if len(colors) != 1 || colors[0].RGBA != (color.RGBA{R: 30, G: 30, B: 200, A: 255}) {
+		t.Errorf("Unexpected colors of region: %v", colors)
+	}
+	// Sizes overflowing the number of pixels are rejected.
+	src.width, src.height = math.MaxInt/2, 4
+	if colors := dominantcolor.FindPixelsWeight(src, 3); len(colors) != 0 {
+		t.Errorf("Unexpected colors of overflowing source: %v", colors)
+	}
+}
+
+func TestFindWeightHistogram(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, image.Rect(0, 0, 10, 6), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 6, 10, 9), image.NewUniform(color.RGBA{B: 200, A: 255}), image.Point{}, draw.Src)
+	colors, vec := dominantcolor.FindWeightHistogram(img, 2, 3)
+	if len(colors) != 2 || colors[0].RGBA != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	if len(vec) != 512 {
+		t.Fatalf("Unexpected histogram size %d", len(vec))
+	}
+	for i, v := range vec {
+		var want float64
+		switch i {
+		case 7 << 6: // Red
+			want = 6.0 / 9
+		case 6: // Blue
+			want = 3.0 / 9
+		}
+		if math.Abs(v-want) > 1e-9 {
+			t.Errorf("Unexpected bin %d: %f, want %f", i, v, want)
+		}
+	}
+	if _, vec := dominantcolor.FindWeightHistogram(img, 2, 8); len(vec) != 1<<18 {
+		t.Errorf("Unexpected histogram size %d for 8 bits, want it clamped to 6 bits", len(vec))
+	}
+}
+
+func TestFindConfidence(t *testing.T) {
+	orange := color.RGBA{R: 230, G: 96, B: 10, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(orange), image.Point{}, draw.Src)
+	crisp := dominantcolor.FindConfidence(img)
+	if crisp.RGBA != orange || crisp.Spread != 0 || crisp.Radius != 0 || crisp.Pixels != 10000 {
+		t.Errorf("Unexpected confidence of uniform image: %+v", crisp)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < len(img.Pix); i += 4 {
+		v := uint8(rnd.Intn(60))
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = 100+v, 70+v, 40+v
+	}
+	fuzzy := dominantcolor.FindConfidence(img)
+	if fuzzy.Spread < 1 || fuzzy.Radius <= 0 || fuzzy.Radius > fuzzy.Spread/10 {
+		t.Errorf("Unexpected confidence of noisy image: %+v", fuzzy)
+	}
+}
+
+func TestAmbient(t *testing.T) {
+	frame := func(c color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return img
+	}
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 30, B: 200, A: 255}
+	a := dominantcolor.NewAmbient(time.Second, 20)
+	if c := a.Update(frame(red), 0); c != red {
+		t.Errorf("Unexpected first color %v", c)
+	}
+	// Cutting to blue changes the color by at most 20 per second.
+	previous := red
+	for i := 0; i < 10; i++ {
+		c := a.Update(frame(blue), 100*time.Millisecond)
+		// Allow for rounding to RGB.
+		if d := dominantcolor.DeltaE(c, previous); d > 3 {
+			t.Errorf("Color changed by %f in 100ms", d)
+		}
+		previous = c
+	}
+	if previous == red || dominantcolor.DeltaE(previous, red) < 19 {
+		t.Errorf("Unexpected color after 1s: %v", previous)
+	}
+	// The color eventually settles on blue.
+	for i := 0; i < 100; i++ {
+		previous = a.Update(frame(blue), time.Second)
+	}
+	if d := dominantcolor.DeltaE(previous, blue); d > 1 {
+		t.Errorf("Unexpected settled color %v", previous)
+	}
+}
+
+func TestTransferPalette(t *testing.T) {
+	halves := func(top, bottom color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+		draw.Draw(img, image.Rect(0, 0, 40, 20), image.NewUniform(top), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(0, 20, 40, 40), image.NewUniform(bottom), image.Point{}, draw.Src)
+		return img
+	}
+	darkBlue := color.RGBA{R: 20, G: 40, B: 120, A: 255}
+	lightBlue := color.RGBA{R: 120, G: 160, B: 230, A: 255}
+	src := halves(darkBlue, lightBlue)
+	dst := halves(color.RGBA{R: 220, G: 120, B: 100, A: 255}, color.RGBA{R: 110, G: 20, B: 10, A: 255})
+	out := dominantcolor.TransferPalette(src, dst)
+	if out == nil || out.Bounds() != dst.Bounds() {
+		t.Fatalf("Unexpected result: %v", out)
+	}
+	at := func(x, y int) color.RGBA { return color.RGBAModel.Convert(out.At(x, y)).(color.RGBA) }
+	top, bottom := at(0, 0), at(0, 39)
+	for _, c := range []color.RGBA{top, bottom} {
+		if int(c.B) < int(c.R)+50 {
+			t.Errorf("Color %v is not blue", c)
+		}
+	}
+	// Lightness is kept in order.
+	if dominantcolor.RelativeLuminance(top) <= dominantcolor.RelativeLuminance(bottom) {
+		t.Errorf("Top color %v is not lighter than bottom color %v", top, bottom)
+	}
+}
+
+func TestDuotone(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 1))
+	img.Pix = []uint8{0, 128, 255}
+	navy := color.RGBA{R: 20, G: 30, B: 90, A: 255}
+	pink := color.RGBA{R: 250, G: 180, B: 200, A: 255}
+	out := dominantcolor.Duotone(img, []color.RGBA{pink, navy})
+	if out == nil {
+		t.Fatal("No result")
+	}
+	at := func(x int) color.RGBA { return color.RGBAModel.Convert(out.At(x, 0)).(color.RGBA) }
+	if at(0) != navy || at(2) != pink {
+		t.Errorf("Unexpected ends of the gradient: %v, %v", at(0), at(2))
+	}
+	if mid := at(1); dominantcolor.DeltaE(mid, navy) < 10 || dominantcolor.DeltaE(mid, pink) < 10 {
+		t.Errorf("Unexpected middle color %v", mid)
+	}
+}
+
+func TestAdjustColors(t *testing.T) {
+	orange := color.RGBA{R: 230, G: 96, B: 10, A: 255}
+	lh := func(c color.RGBA) (l, h float64) {
+		hsl := dominantcolor.ToHSLuv(c)
+		return hsl.L, hsl.H
+	}
+	l0, h0 := lh(orange)
+	if l, h := lh(dominantcolor.Lighten(orange, 10)); l <= l0 || math.Abs(h-h0) > 5 {
+		t.Errorf("Lighten changed lightness from %f to %f and hue from %f to %f", l0, l, h0, h)
+	}
+	if l, h := lh(dominantcolor.Darken(orange, 10)); l >= l0 || math.Abs(h-h0) > 5 {
+		t.Errorf("Darken changed lightness from %f to %f and hue from %f to %f", l0, l, h0, h)
+	}
+	if c := dominantcolor.Saturate(orange, -200); c.R != c.G || c.G != c.B {
+		t.Errorf("Desaturated color %v is not gray", c)
+	}
+	if c := dominantcolor.Rotate(orange, 360); dominantcolor.DeltaE(c, orange) > 1 {
+		t.Errorf("Full rotation changed %v to %v", orange, c)
+	}
+	if c := dominantcolor.Rotate(orange, 180); c.B <= c.R {
+		t.Errorf("Opposite of orange %v is not blue", c)
+	}
+	// Out of gamut results keep their lightness.
+	if c := dominantcolor.Saturate(orange, 200); dominantcolor.DeltaE(c, orange) > 10 {
+		t.Errorf("Oversaturated color %v is far from %v", c, orange)
+	}
+	half := color.RGBA{R: 100, G: 40, B: 0, A: 128}
+	if c := dominantcolor.Rotate(half, 0); c.A != 128 || dominantcolor.DeltaE(c, half) > 2 {
+		t.Errorf("Unexpected rotation of translucent color: %v", c)
+	}
+}
+
+func TestSpreadPalette(t *testing.T) {
+	colors := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 200, G: 40, B: 40, A: 255}, Weight: 0.4},
+		{RGBA: color.RGBA{R: 205, G: 45, B: 40, A: 255}, Weight: 0.3},
+		{RGBA: color.RGBA{R: 40, G: 60, B: 200, A: 255}, Weight: 0.2},
+		{RGBA: color.RGBA{R: 128, G: 128, B: 128, A: 255}, Weight: 0.1},
+	}
+	spread := dominantcolor.SpreadPalette(colors, 20)
+	if len(spread) != len(colors) {
+		t.Fatalf("Unexpected number of colors: %d", len(spread))
+	}
+	for i := range spread {
+		if spread[i].Weight != colors[i].Weight {
+			t.Errorf("Weight of color %d changed from %f to %f", i, colors[i].Weight, spread[i].Weight)
+		}
+		for j := i + 1; j < len(spread); j++ {
+			if d := dominantcolor.DeltaE(spread[i].RGBA, spread[j].RGBA); d < 19 {
+				t.Errorf("Colors %v and %v are too close: %f", spread[i].RGBA, spread[j].RGBA, d)
+			}
+		}
+	}
+	for _, i := range []int{0, 1} {
+		if c := spread[i]; c.R <= c.G || c.R <= c.B {
+			t.Errorf("Red color %d became %v", i, c.RGBA)
+		}
+	}
+	if c := spread[3]; c.R != c.G || c.G != c.B {
+		t.Errorf("Gray became %v", c.RGBA)
+	}
+	// Distinct colors are unchanged.
+	if got := dominantcolor.SpreadPalette(colors[2:], 20); got[0].RGBA != colors[2].RGBA || got[1].RGBA != colors[3].RGBA {
+		t.Errorf("Distinct colors changed: %v", got)
+	}
+}
+
+func TestCategoricalPalette(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	draw.Draw(img, img.Bounds(), &image.Uniform{red}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 40, 15), &image.Uniform{blue}, image.Point{}, draw.Src)
+	palette := dominantcolor.CategoricalPalette(img, 6)
+	if len(palette) != 6 {
+		t.Fatalf("Unexpected number of colors: %d", len(palette))
+	}
+	if dominantcolor.DeltaE(palette[0], red) > 5 || dominantcolor.DeltaE(palette[1], blue) > 5 {
+		t.Errorf("Palette does not start with the image colors: %v", palette)
+	}
+	for i := range palette {
+		for j := i + 1; j < len(palette); j++ {
+			if d := dominantcolor.DeltaE(palette[i], palette[j]); d < 20 {
+				t.Errorf("Colors %v and %v are too close: %f", palette[i], palette[j], d)
+			}
+		}
+	}
+	if palette := dominantcolor.CategoricalPalette(nil, 6); palette != nil {
+		t.Errorf("Unexpected palette for nil image: %v", palette)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, image.Rect(0, 0, 40, 20), image.NewUniform(red), image.Point{}, draw.Src)
+	report := dominantcolor.Analyze(img)
+	if len(report.Palette) != 1 || report.Palette[0].RGBA != red || math.Abs(report.Palette[0].Weight-0.5) > 1e-9 {
+		t.Errorf("Unexpected palette: %v", report.Palette)
+	}
+	if report.Average != red {
+		t.Errorf("Unexpected average color: %v", report.Average)
+	}
+	if math.Abs(report.Transparency-0.5) > 1e-9 {
+		t.Errorf("Unexpected transparency: %f", report.Transparency)
+	}
+	// Luma of the red is about 75.
+	if report.Luminance[4] != 1 {
+		t.Errorf("Unexpected luminance histogram: %v", report.Luminance)
+	}
+	if report.Colorfulness < 50 {
+		t.Errorf("Red is not colorful: %f", report.Colorfulness)
+	}
+	if report.Temperature.Warm != 0.5 {
+		t.Errorf("Unexpected temperature: %+v", report.Temperature)
+	}
+
+	gray := dominantcolor.Analyze(image.NewUniform(color.Gray{Y: 128}))
+	if gray.Colorfulness != 0 || gray.Transparency != 0 || gray.Luminance[8] != 1 {
+		t.Errorf("Unexpected report for gray image: %+v", gray)
+	}
+	if report := dominantcolor.Analyze(nil); report.Palette != nil {
+		t.Errorf("Unexpected report for nil image: %+v", report)
+	}
+
+	// Channels above alpha are not valid premultiplied colors, but must
+	// not make Analyze panic.
+	invalid := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range invalid.Pix {
+		invalid.Pix[i] = 0xff
+		if i%4 == 3 {
+			invalid.Pix[i] = 1
+		}
+	}
+	if report := dominantcolor.Analyze(invalid); report.Luminance[15] != 1 {
+		t.Errorf("Unexpected luminance histogram of invalid image: %v", report.Luminance)
+	}
+}
+
+func TestSwatches(t *testing.T) {
+	colors := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 200, G: 30, B: 30, A: 255}, Weight: 0.3},
+		{RGBA: color.RGBA{R: 30, G: 60, B: 200, A: 255}, Weight: 0.2},
+	}
+	want := "\x1b[38;2;200;30;30m██\x1b[0m #C81E1E 60%  \x1b[38;2;30;60;200m██\x1b[0m #1E3CC8 40%"
+	if s := dominantcolor.Swatches(colors, dominantcolor.NoEmoji); s != want {
+		t.Errorf("Unexpected swatches: %q, want %q", s, want)
+	}
+	if s := dominantcolor.Swatches(colors, dominantcolor.Circles); s != "🔴 #C81E1E 60%  🔵 #1E3CC8 40%" {
+		t.Errorf("Unexpected circle swatches: %q", s)
+	}
+	unweighted := []dominantcolor.Color{{RGBA: color.RGBA{R: 250, G: 250, B: 250, A: 255}}}
+	if s := dominantcolor.Swatches(unweighted, dominantcolor.Hearts); s != "🤍 #FAFAFA" {
+		t.Errorf("Unexpected heart swatches: %q", s)
+	}
+	if e := dominantcolor.NearestEmoji(color.RGBA{R: 250, G: 140, B: 0, A: 255}, dominantcolor.Hearts); e != "🧡" {
+		t.Errorf("Unexpected emoji for orange: %s", e)
+	}
+}
+
+func TestChatPayloads(t *testing.T) {
+	colors := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 200, G: 30, B: 30, A: 255}, Weight: 0.3},
+		{RGBA: color.RGBA{R: 30, G: 60, B: 200, A: 255}, Weight: 0.2},
+	}
+	slack, err := dominantcolor.SlackPayload("Palette", colors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"text":"Palette","attachments":[` +
+		`{"color":"#C81E1E","blocks":[{"type":"section","text":{"type":"mrkdwn","text":"` + "`#C81E1E`" + ` 60%"}}]},` +
+		`{"color":"#1E3CC8","blocks":[{"type":"section","text":{"type":"mrkdwn","text":"` + "`#1E3CC8`" + ` 40%"}}]}]}`
+	if string(slack) != want {
+		t.Errorf("Unexpected Slack payload:\n%s\nwant\n%s", slack, want)
+	}
+
+	many := make([]dominantcolor.Color, 12)
+	for i := range many {
+		many[i] = colors[i%2]
+	}
+	discord, err := dominantcolor.DiscordPayload("", many)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Content string
+		Embeds  []struct {
+			Color       int
+			Description string
+		}
+	}
+	if err := json.Unmarshal(discord, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Embeds) != 10 || payload.Content != "" {
+		t.Fatalf("Unexpected Discord payload: %s", discord)
+	}
+	if e := payload.Embeds[0]; e.Color != 0xC81E1E || e.Description != "`#C81E1E` 10%" {
+		t.Errorf("Unexpected embed: %+v", e)
+	}
+}
+
+// jpegWithThumbnail returns a JPEG file of a uniform image of the given size
+// and color, with thumb as its EXIF thumbnail.
+func jpegWithThumbnail(t *testing.T, width, height int, c color.Color, thumb image.Image) []byte {
+	t.Helper()
+	var full, small bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	if err := jpeg.Encode(&full, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&small, thumb, nil); err != nil {
+		t.Fatal(err)
+	}
+	// TIFF header, an empty IFD0 and an IFD1 pointing to the thumbnail.
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 0, 0, 14, 0, 0, 0, 2, 0}
+	entry := func(tag uint16, value int) {
+		tiff = append(tiff, byte(tag), byte(tag>>8), 4, 0, 1, 0, 0, 0, byte(value), byte(value>>8), 0, 0)
+	}
+	entry(0x0201, 44)
+	entry(0x0202, small.Len())
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, small.Bytes()...)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xff, 0xd8, 0xff, 0xe1, byte((len(app1) + 2) >> 8), byte(len(app1) + 2)}
+	data = append(data, app1...)
+	return append(data, full.Bytes()[2:]...)
+}
+
+func TestDecodeAndFind_EXIFThumbnail(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	// A 4:1 image with a thumbnail padded to 4:3 with green bars.
+	thumb := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	draw.Draw(thumb, thumb.Bounds(), image.NewUniform(color.RGBA{R: 30, G: 180, B: 30, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(thumb, image.Rect(0, 8, 32, 16), image.NewUniform(blue), image.Point{}, draw.Src)
+	data := jpegWithThumbnail(t, 256, 64, red, thumb)
+
+	c, err := dominantcolor.DecodeAndFind(bytes.NewReader(data), dominantcolor.DecodeLimits{}, dominantcolor.WithEXIFThumbnail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := dominantcolor.DeltaE(c, blue); d > 10 {
+		t.Errorf("Color of the thumbnail is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(blue))
+	}
+	// The option survives a round trip through a configuration file.
+	b, err := json.Marshal(dominantcolor.Config{EXIFThumbnail: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config dominantcolor.Config
+	if err := json.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = dominantcolor.DecodeAndFind(bytes.NewReader(data), dominantcolor.DecodeLimits{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := dominantcolor.DeltaE(c, blue); d > 10 {
+		t.Errorf("Color of the thumbnail from config %s is %s, want %s", b, dominantcolor.Hex(c), dominantcolor.Hex(blue))
+	}
+	c, err = dominantcolor.DecodeAndFind(bytes.NewReader(data), dominantcolor.DecodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := dominantcolor.DeltaE(c, red); d > 10 {
+		t.Errorf("Color of the image is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(red))
+	}
+	// Images without a thumbnail are decoded in full.
+	var plain bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	if err := jpeg.Encode(&plain, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	c, err = dominantcolor.DecodeAndFind(&plain, dominantcolor.DecodeLimits{}, dominantcolor.WithEXIFThumbnail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := dominantcolor.DeltaE(c, red); d > 10 {
+		t.Errorf("Color of the image without thumbnail is %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(red))
+	}
+}
+
+func TestFind_ForLogos(t *testing.T) {
+	red := color.NRGBA{R: 220, G: 20, B: 40, A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	// Thin strokes with an anti-aliased fringe blended with a white matte.
+	for x := 0; x < 40; x++ {
+		for _, y := range []int{10, 20, 30} {
+			img.SetNRGBA(x, y, red)
+			img.SetNRGBA(x, y-1, color.NRGBA{R: 240, G: 160, B: 170, A: 0x70})
+			img.SetNRGBA(x, y+1, color.NRGBA{R: 240, G: 160, B: 170, A: 0x70})
+		}
+	}
+	want := color.RGBA{R: red.R, G: red.G, B: red.B, A: 255}
+	if c := dominantcolor.Find(img); dominantcolor.DeltaE(c, want) < 5 {
+		t.Fatalf("Fringe does not wash out the color: %s", dominantcolor.Hex(c))
+	}
+	if c := dominantcolor.Find(img, dominantcolor.ForLogos()); c != want {
+		t.Errorf("Unexpected color: %s, want %s", dominantcolor.Hex(c), dominantcolor.Hex(want))
+	}
+	config := dominantcolor.Config{Preset: "logos"}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := dominantcolor.Find(img, opts...); c != want {
+		t.Errorf("Unexpected color with the logos preset: %s", dominantcolor.Hex(c))
+	}
+}
+
+func TestFindProduct(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 250, G: 250, B: 246, A: 255}), image.Point{}, draw.Src)
+	// A red product with a white label.
+	draw.Draw(img, image.Rect(20, 15, 40, 45), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(25, 25, 35, 35), image.NewUniform(white), image.Point{}, draw.Src)
+
+	colors, confidence := dominantcolor.FindProduct(img, 2)
+	if confidence != 1 {
+		t.Errorf("Unexpected background confidence: %f", confidence)
+	}
+	if len(colors) != 2 || colors[0].RGBA != red || colors[1].RGBA != white || math.Abs(colors[0].Weight-5.0/6) > 1e-9 {
+		t.Errorf("Unexpected product colors: %v", colors)
+	}
+	if c := dominantcolor.Find(img, dominantcolor.ForProducts()); c != red {
+		t.Errorf("Unexpected color with ForProducts: %s", dominantcolor.Hex(c))
+	}
+
+	// Images without a white background are analyzed in full.
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 30, G: 60, B: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(20, 15, 40, 45), image.NewUniform(red), image.Point{}, draw.Src)
+	colors, confidence = dominantcolor.FindProduct(img, 2)
+	if confidence != 0 || len(colors) != 2 || colors[0].B != 200 {
+		t.Errorf("Unexpected colors without background: %v, confidence %f", colors, confidence)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 10, 40), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	report := dominantcolor.Validate(img, dominantcolor.FindWeight(img, 2))
+	if report.MeanDeltaE != 0 || report.Unrepresented != 0 {
+		t.Errorf("Unexpected report for exact palette: %+v", report)
+	}
+	report = dominantcolor.Validate(img, []dominantcolor.Color{{RGBA: red, Weight: 1}})
+	want := dominantcolor.DeltaE(red, blue) / 4
+	if math.Abs(report.MeanDeltaE-want) > 1e-9 || report.Unrepresented != 0.25 {
+		t.Errorf("Unexpected report for missing color: %+v, want mean %f", report, want)
+	}
+	if report := dominantcolor.Validate(img, nil); !math.IsInf(report.MeanDeltaE, 1) || report.Unrepresented != 1 {
+		t.Errorf("Unexpected report for empty palette: %+v", report)
+	}
+	for _, img := range []image.Image{nil, image.NewRGBA(image.Rect(0, 0, 10, 10))} {
+		if report := dominantcolor.Validate(img, nil); !math.IsNaN(report.MeanDeltaE) || !math.IsNaN(report.Unrepresented) {
+			t.Errorf("Unexpected report for image without pixels: %+v", report)
+		}
+	}
+}
+
+func TestFindAdaptive(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 30, B: 30, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 10, 40), image.NewUniform(color.RGBA{R: 30, G: 60, B: 200, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(10, 0, 20, 40), image.NewUniform(color.RGBA{R: 30, G: 160, B: 60, A: 255}), image.Point{}, draw.Src)
+	if colors := dominantcolor.FindAdaptive(img, 1, 0, dominantcolor.WithSeeding(dominantcolor.KMeansPlusPlus)); len(colors) != 3 {
+		t.Errorf("Unexpected colors: %v", colors)
+	}
+	// A loose target needs fewer colors.
+	if colors := dominantcolor.FindAdaptive(img, 40, 0); len(colors) >= 3 {
+		t.Errorf("Unexpected colors for loose target: %v", colors)
+	}
+	photo := testImage(t)
+	colors := dominantcolor.FindAdaptive(photo, 5, 8)
+	if len(colors) == 0 || len(colors) > 8 {
+		t.Fatalf("Unexpected number of colors: %d", len(colors))
+	}
+	if len(colors) < 8 {
+		if d := dominantcolor.Validate(photo, colors).MeanDeltaE; d > 5 {
+			t.Errorf("Target is not reached with %d colors: %f", len(colors), d)
+		}
+	}
+}
+
+func TestReducePalette(t *testing.T) {
+	red := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	colors := []dominantcolor.Color{
+		{RGBA: red, Weight: 0.3},
+		{RGBA: blue, Weight: 0.3},
+		{RGBA: color.RGBA{R: 205, G: 35, B: 30, A: 255}, Weight: 0.1},
+		{RGBA: color.RGBA{R: 30, G: 60, B: 190, A: 255}, Weight: 0.2},
+		{RGBA: color.RGBA{R: 30, G: 160, B: 60, A: 255}, Weight: 0.1},
+	}
+	reduced := dominantcolor.ReducePalette(colors, 3)
+	if len(reduced) != 3 {
+		t.Fatalf("Unexpected number of colors: %v", reduced)
+	}
+	if reduced[0].B < 150 || math.Abs(reduced[0].Weight-0.5) > 1e-9 {
+		t.Errorf("Blues are not merged: %v", reduced)
+	}
+	if reduced[1].R < 150 || math.Abs(reduced[1].Weight-0.4) > 1e-9 || dominantcolor.DeltaE(reduced[1].RGBA, red) > 3 {
+		t.Errorf("Reds are not merged: %v", reduced)
+	}
+	if reduced[2] != colors[4] {
+		t.Errorf("Distinct color is not kept: %v", reduced[2])
+	}
+	if reduced := dominantcolor.ReducePalette(colors, 1); len(reduced) != 1 || math.Abs(reduced[0].Weight-1) > 1e-9 {
+		t.Errorf("Unexpected single color: %v", reduced)
+	}
+	if reduced := dominantcolor.ReducePalette(colors, 10); len(reduced) != len(colors) || reduced[0] != colors[0] {
+		t.Errorf("Small palette changed: %v", reduced)
+	}
+}
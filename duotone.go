@@ -0,0 +1,44 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// Duotone returns a copy of img where the lightness of each pixel is mapped
+// onto a gradient through colors, from the darkest color for black pixels
+// to the lightest one for white pixels, producing the duotone effect with
+// two colors and a tritone with three. Colors are ordered by lightness and
+// blended as by Interpolate, so the colors returned by FindN can be used
+// directly. Alpha is kept. It returns nil if img is invalid or colors is
+// empty.
+func Duotone(img image.Image, colors []color.RGBA) *image.NRGBA {
+	if ValidateInput(img, 0) != nil || len(colors) == 0 {
+		return nil
+	}
+	stops := make([]Color, len(colors))
+	for i, c := range colors {
+		stops[i] = Color{RGBA: c}
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		return rgbToLab(stops[i].R, stops[i].G, stops[i].B).L < rgbToLab(stops[j].R, stops[j].G, stops[j].B).L
+	})
+	ramp := Steps(stops, 256)
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	cache := make(map[color.NRGBA]color.NRGBA)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			t, ok := cache[c]
+			if !ok {
+				r := ramp[clampChannel(rgbToLab(c.R, c.G, c.B).L*0xff/100)]
+				t = color.NRGBA{R: r.R, G: r.G, B: r.B, A: c.A}
+				cache[c] = t
+			}
+			out.SetNRGBA(x, y, t)
+		}
+	}
+	return out
+}
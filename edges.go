@@ -0,0 +1,43 @@
+package dominantcolor
+
+import "math"
+
+// Sobel gradient magnitude of the luma above which a pixel is considered
+// to be on an edge.
+const edgeThreshold = 128
+
+// downweightEdges multiplies the weight of points lying on an edge by w.
+// Edges are detected with a Sobel operator on the luma of the row-major
+// grid of points. Colors at edges are blended by anti-aliasing and
+// compression artifacts, so they make poor cluster members.
+func downweightEdges(points []point, width, height int, w float64) {
+	luma := make([]float64, len(points))
+	for i, p := range points {
+		luma[i] = 0.299*p.v[0] + 0.587*p.v[1] + 0.114*p.v[2]
+	}
+	at := func(x, y int) float64 {
+		// Replicate the border pixels.
+		if x < 0 {
+			x = 0
+		} else if x >= width {
+			x = width - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y >= height {
+			y = height - 1
+		}
+		return luma[y*width+x]
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			if math.Hypot(gx, gy) > edgeThreshold {
+				points[y*width+x].weight *= w
+			}
+		}
+	}
+}
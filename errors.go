@@ -0,0 +1,55 @@
+package dominantcolor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"reflect"
+)
+
+// Largest number of clusters accepted.
+const maxClusters = 256
+
+var (
+	// ErrNilImage is returned when the image is nil.
+	ErrNilImage = errors.New("dominantcolor: nil image")
+	// ErrEmptyImage is returned when the image has no pixels.
+	ErrEmptyImage = errors.New("dominantcolor: empty image")
+	// ErrEmptyPalette is returned when a palette has no colors.
+	ErrEmptyPalette = errors.New("dominantcolor: empty palette")
+)
+
+// ClusterCountError is returned when the requested number of clusters is
+// larger than the supported maximum.
+type ClusterCountError struct {
+	N int
+}
+
+func (e *ClusterCountError) Error() string {
+	return fmt.Sprintf("dominantcolor: invalid number of clusters %d, must be at most %d", e.N, maxClusters)
+}
+
+// ValidateInput checks whether img and nClusters are acceptable inputs.
+// The Find functions never panic; for invalid inputs they return empty
+// results, and ValidateInput reports the reason.
+func ValidateInput(img image.Image, nClusters int) error {
+	if isNil(img) {
+		return ErrNilImage
+	}
+	if img.Bounds().Empty() {
+		return ErrEmptyImage
+	}
+	if nClusters > maxClusters {
+		return &ClusterCountError{N: nClusters}
+	}
+	return nil
+}
+
+// isNil returns whether img is nil or an interface holding a nil pointer.
+func isNil(img image.Image) bool {
+	if img == nil {
+		return true
+	}
+	v := reflect.ValueOf(img)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,53 @@
+package dominantcolor
+
+import (
+	"image"
+	"math"
+)
+
+// EstimateDistinctColors estimates the number of distinct colors of img.
+// When the image is resized for processing, the pixels of the working
+// image are only a sample of the image, and colors occurring once in the
+// sample indicate how many colors were missed: following Good and Turing,
+// the fraction of the image covered by sampled colors is estimated as one
+// minus the fraction of the sample taken by colors seen once, and the
+// number of sampled colors is divided by this coverage. The estimate never
+// exceeds the number of pixels. Transparent and excluded pixels are not
+// counted, and colors are counted after quantization. It returns 0 for
+// invalid images.
+func EstimateDistinctColors(img image.Image, opts ...Option) int {
+	if ValidateInput(img, 0) != nil {
+		return 0
+	}
+	o := newOptions(opts)
+	mask := newWeightMask(img, o)
+	working := o.resize(img)
+	bounds := working.Bounds()
+	counts := make(map[vector]int)
+	var n int
+	for _, p := range imagePoints(working, mask.scaled(bounds), o) {
+		if p.weight == 0 {
+			continue
+		}
+		counts[vector{p.v[0], p.v[1], p.v[2]}]++
+		n++
+	}
+	distinct := len(counts)
+	if bounds.Size() == img.Bounds().Size() || n == 0 {
+		return distinct
+	}
+	var singletons int
+	for _, c := range counts {
+		if c == 1 {
+			singletons++
+		}
+	}
+	// Number of counted pixels at the original resolution.
+	scale := float64(img.Bounds().Dx()) * float64(img.Bounds().Dy()) / (float64(bounds.Dx()) * float64(bounds.Dy()))
+	pixels := float64(n) * scale
+	coverage := 1 - float64(singletons)/float64(n)
+	if coverage == 0 {
+		return int(math.Round(pixels))
+	}
+	return int(math.Round(math.Min(float64(distinct)/coverage, pixels)))
+}
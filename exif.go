@@ -0,0 +1,114 @@
+package dominantcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// EXIF tags of the offset and length of the JPEG thumbnail in IFD1.
+const (
+	tagThumbnailOffset = 0x0201
+	tagThumbnailLength = 0x0202
+)
+
+// exifThumbnail returns the JPEG thumbnail embedded in the EXIF metadata of
+// the start of a JPEG file, or nil if there is none. data must hold the
+// file up to the APP1 segment holding the metadata.
+func exifThumbnail(data []byte) []byte {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil
+	}
+	// Walk the segments before the image data.
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		if marker == 0xda || marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			// Start of scan or of frame: the metadata is over.
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		if payload := data[i+4 : end]; marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return tiffThumbnail(payload[6:])
+		}
+		i = end
+	}
+	return nil
+}
+
+// tiffThumbnail returns the thumbnail referenced by the second IFD of the
+// TIFF structure of EXIF metadata.
+func tiffThumbnail(tiff []byte) []byte {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	// Skip IFD0 to find IFD1.
+	ifd0 := int(order.Uint32(tiff[4:]))
+	if ifd0 < 8 || ifd0+2 > len(tiff) {
+		return nil
+	}
+	next := ifd0 + 2 + 12*int(order.Uint16(tiff[ifd0:]))
+	if next+4 > len(tiff) {
+		return nil
+	}
+	ifd1 := int(order.Uint32(tiff[next:]))
+	if ifd1 < 8 || ifd1+2 > len(tiff) {
+		return nil
+	}
+	var offset, length int
+	for i, n := 0, int(order.Uint16(tiff[ifd1:])); i < n; i++ {
+		entry := ifd1 + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return nil
+		}
+		// Both tags are single LONG values, stored in the value field.
+		switch order.Uint16(tiff[entry:]) {
+		case tagThumbnailOffset:
+			offset = int(order.Uint32(tiff[entry+8:]))
+		case tagThumbnailLength:
+			length = int(order.Uint32(tiff[entry+8:]))
+		}
+	}
+	if offset < 8 || length <= 0 || offset+length > len(tiff) {
+		return nil
+	}
+	return tiff[offset : offset+length]
+}
+
+// thumbnailContent returns the part of a thumbnail showing the content of
+// an image of the given size. Cameras pad thumbnails to 4:3 with black
+// bars when the aspect ratio of the image differs, which would count as
+// dominant colors.
+func thumbnailContent(thumb image.Image, width, height int) image.Image {
+	sub, ok := thumb.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok || width <= 0 || height <= 0 {
+		return thumb
+	}
+	r := thumb.Bounds()
+	dx, dy := r.Dx(), r.Dy()
+	if h := dx * height / width; h < dy-1 {
+		r.Min.Y += (dy - h) / 2
+		r.Max.Y = r.Min.Y + h
+	} else if w := dy * width / height; w < dx-1 {
+		r.Min.X += (dx - w) / 2
+		r.Max.X = r.Min.X + w
+	}
+	if r.Empty() {
+		return thumb
+	}
+	return sub.SubImage(r)
+}
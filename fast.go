@@ -0,0 +1,65 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+const (
+	// Number of strata along each axis sampled by FindFast.
+	fastGridSize = 64
+	// Number of KMean iterations run by FindFast.
+	fastIterations = 3
+)
+
+// FindFast returns an approximation of the dominant color in img.
+//
+// Instead of resizing the image and running the clustering until
+// convergence, it samples one pixel from each cell of a 64x64 grid laid
+// over the image and refines the clusters for a fixed number of
+// iterations. This trades accuracy for speed where throughput matters more
+// than precision, such as thumbnail servers.
+func FindFast(img image.Image, opts ...Option) color.RGBA {
+	if ValidateInput(img, nClustersDefault) != nil {
+		return color.RGBA{}
+	}
+	o := newOptions(opts)
+	points, width, height := stratifiedPoints(img, fastGridSize, newWeightMask(img, o), o)
+	clusters := clusterPoints(points, width, height, nClustersDefault, fastIterations, o)
+	return o.pick(clusterColors(clusters, float64(len(points))))
+}
+
+// stratifiedPoints divides img into a grid of at most n by n cells and
+// samples a random pixel from each cell. Points are returned in row-major
+// order along with the dimensions of the grid. The weight of each point
+// is scaled by mask.
+func stratifiedPoints(img image.Image, n int, mask *weightMask, o *options) (points []point, width, height int) {
+	bounds := img.Bounds()
+	width, height = n, n
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	if bounds.Dy() < height {
+		height = bounds.Dy()
+	}
+	rnd := rand.New(rand.NewSource(0))
+	points = make([]point, 0, width*height)
+	for j := 0; j < height; j++ {
+		y0 := bounds.Min.Y + j*bounds.Dy()/height
+		y1 := bounds.Min.Y + (j+1)*bounds.Dy()/height
+		for i := 0; i < width; i++ {
+			x0 := bounds.Min.X + i*bounds.Dx()/width
+			x1 := bounds.Min.X + (i+1)*bounds.Dx()/width
+			x, y := x0+rnd.Intn(x1-x0), y0+rnd.Intn(y1-y0)
+			p := o.colorPoint(rgbaAt(img, x, y))
+			p.weight *= mask.weight(x, y)
+			if o.spatialWeight > 0 {
+				p.v[3] = normalizedCoordinate(i, width) * o.spatialWeight
+				p.v[4] = normalizedCoordinate(j, height) * o.spatialWeight
+			}
+			points = append(points, p)
+		}
+	}
+	return points, width, height
+}
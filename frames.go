@@ -0,0 +1,85 @@
+package dominantcolor
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// FrameSource iterates over the frames of an animation, such as an
+// animated GIF, WebP or APNG image, or a video.
+type FrameSource interface {
+	// Next returns the next frame, fully composited, and how long it is
+	// displayed. The frame may be reused by the following call. Next
+	// returns io.EOF after the last frame.
+	Next() (image.Image, time.Duration, error)
+}
+
+// FrameSourceFunc is an adapter to allow the use of ordinary functions,
+// such as the iterator of an external decoder, as FrameSources.
+type FrameSourceFunc func() (image.Image, time.Duration, error)
+
+// Next returns f().
+func (f FrameSourceFunc) Next() (image.Image, time.Duration, error) {
+	return f()
+}
+
+// NewGIFSource returns a FrameSource compositing the frames of g according
+// to their disposal methods. If g is nil, Next returns io.EOF right away.
+func NewGIFSource(g *gif.GIF) FrameSource {
+	if g == nil {
+		g = &gif.GIF{}
+	}
+	return gifSource{newGIFPlayer(g)}
+}
+
+type gifSource struct {
+	p *gifPlayer
+}
+
+func (s gifSource) Next() (image.Image, time.Duration, error) {
+	frame, delay, ok := s.p.next()
+	if !ok {
+		return nil, 0, io.EOF
+	}
+	return frame, delay, nil
+}
+
+// FindFrames is like FindGIF but reads the frames from src. It returns the
+// results for the frames read so far along with any error returned by src
+// other than io.EOF.
+func FindFrames(src FrameSource, opts ...Option) (colors []color.RGBA, changes []int, err error) {
+	o := newOptions(opts)
+	for {
+		frame, _, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return colors, changes, nil
+		}
+		if err != nil {
+			return colors, changes, err
+		}
+		c := Find(frame, opts...)
+		if i := len(colors); i == 0 || DeltaE(c, colors[i-1]) > o.diffTolerance {
+			changes = append(changes, i)
+		}
+		colors = append(colors, c)
+	}
+}
+
+// AddFrames adds every frame of src to the Accumulator. It returns any
+// error returned by src other than io.EOF.
+func (a *Accumulator) AddFrames(src FrameSource) error {
+	for {
+		frame, _, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.Add(frame)
+	}
+}
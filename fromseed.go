@@ -0,0 +1,26 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// FindFromSeed is like FindWeight but starts the KMean algorithm from the
+// colors of previous, a prior result such as the palette of the previous
+// frame of a video or of the previous version of an edited image, instead
+// of random pixels. It finds as many colors as previous has, in a single
+// or a few iterations when the image changed little, and the colors follow
+// the changes of the image instead of jumping between runs. The weights of
+// previous are ignored. If previous is empty, it is like FindWeight with
+// the default number of clusters.
+func FindFromSeed(img image.Image, previous []Color, opts ...Option) []Color {
+	if len(previous) == 0 {
+		return FindWeight(img, 0, opts...)
+	}
+	seeds := make([]color.RGBA, len(previous))
+	for i, c := range previous {
+		seeds[i] = c.RGBA
+	}
+	opts = append([]Option{func(o *options) { o.seeds = seeds }}, opts...)
+	return FindWeight(img, len(previous), opts...)
+}
@@ -0,0 +1,90 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// FindGIF returns the dominant color of each frame of the animated GIF g,
+// as Find would return for the frame composited over the previous ones,
+// and the indices of the frames where the dominant color changes, starting
+// with 0. The color changes when it differs from the color of the previous
+// frame by more than the tolerance set with WithDiffTolerance. This is
+// useful to sync animated theme effects with a GIF. It returns no colors if
+// g is nil or has no frames.
+func FindGIF(g *gif.GIF, opts ...Option) (colors []color.RGBA, changes []int) {
+	if g == nil || len(g.Image) == 0 {
+		return nil, nil
+	}
+	// Frames of a GIF never fail to be read.
+	colors, changes, _ = FindFrames(NewGIFSource(g), opts...)
+	return colors, changes
+}
+
+// gifPlayer composites the frames of an animated GIF according to their
+// disposal methods.
+type gifPlayer struct {
+	g *gif.GIF
+
+	// Index of the next frame.
+	i int
+
+	canvas *image.RGBA
+
+	// Content of the canvas before the last frame was drawn, used by
+	// frames disposed to the previous content.
+	restore *image.RGBA
+}
+
+func newGIFPlayer(g *gif.GIF) *gifPlayer {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = image.Rectangle{}
+		for _, frame := range g.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+	return &gifPlayer{g: g, canvas: image.NewRGBA(bounds)}
+}
+
+// next returns the next composited frame and its delay. The frame is
+// only valid until the following call.
+func (p *gifPlayer) next() (*image.RGBA, time.Duration, bool) {
+	if p.i >= len(p.g.Image) {
+		return nil, 0, false
+	}
+	if p.i > 0 {
+		prev := p.g.Image[p.i-1].Bounds()
+		switch p.disposal(p.i - 1) {
+		case gif.DisposalBackground:
+			draw.Draw(p.canvas, prev, image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			draw.Draw(p.canvas, prev, p.restore, prev.Min, draw.Src)
+		}
+	}
+	frame := p.g.Image[p.i]
+	if p.disposal(p.i) == gif.DisposalPrevious {
+		if p.restore == nil {
+			p.restore = image.NewRGBA(p.canvas.Bounds())
+		}
+		draw.Draw(p.restore, frame.Bounds(), p.canvas, frame.Bounds().Min, draw.Src)
+	}
+	draw.Draw(p.canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	var delay time.Duration
+	if p.i < len(p.g.Delay) {
+		delay = time.Duration(p.g.Delay[p.i]) * 10 * time.Millisecond
+	}
+	p.i++
+	return p.canvas, delay, true
+}
+
+func (p *gifPlayer) disposal(i int) byte {
+	if i < len(p.g.Disposal) {
+		return p.g.Disposal[i]
+	}
+	return 0
+}
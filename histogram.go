@@ -0,0 +1,205 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// BinCount is a bin of a color histogram.
+type BinCount struct {
+	// Color of the bin, with the bits below the histogram precision
+	// cleared.
+	color.RGBA
+
+	// Number of pixels in the bin, scaled by their weight when a mask or
+	// edge weighting is used.
+	Count float64
+}
+
+// Histogram returns the distribution of colors in the working image
+// analyzed by FindWeight, keeping the bitsPerChannel high-order bits of each
+// channel. Colors whose channels differ only in the discarded bits are
+// counted together. Bins are sorted by decreasing count, and empty bins
+// are omitted. bitsPerChannel is clamped to the range [1, 8], overriding
+// WithQuantizeBits.
+func Histogram(img image.Image, bitsPerChannel int, opts ...Option) []BinCount {
+	if ValidateInput(img, 0) != nil {
+		return []BinCount{}
+	}
+	o := newOptions(opts)
+	WithQuantizeBits(bitsPerChannel)(o)
+	o.spatialWeight = 0
+	mask := newWeightMask(img, o)
+	img = o.resize(img)
+	bounds := img.Bounds()
+	points := imagePoints(img, mask.scaled(bounds), o)
+	if o.edgeWeight < 1 {
+		downweightEdges(points, bounds.Dx(), bounds.Dy(), o.edgeWeight)
+	}
+	bins := []BinCount{}
+	for _, p := range histogramPoints(points) {
+		bins = append(bins, BinCount{
+			RGBA:  color.RGBA{R: uint8(p.v[0]), G: uint8(p.v[1]), B: uint8(p.v[2]), A: 0xff},
+			Count: p.weight,
+		})
+	}
+	sort.SliceStable(bins, func(i, j int) bool { return bins[i].Count > bins[j].Count })
+	return bins
+}
+
+// Largest number of bits per channel of the histogram of
+// FindWeightHistogram.
+const maxHistogramBits = 6
+
+// FindWeightHistogram is like FindWeight but also returns a color
+// histogram of img as a vector of fixed size, for use as a feature of
+// machine learning models. Each channel is divided into 2^bitsPerChannel
+// ranges, so the vector has 2^(3*bitsPerChannel) bins, 512 for 3 bits per
+// channel. The bin of a color is (R<<2b | G<<b | B) where b is
+// bitsPerChannel and R, G and B are the indexes of the ranges of its
+// channels. Counts are normalized to sum to 1, unless the image has no
+// opaque pixels. The histogram is computed from the working image
+// analyzed by FindWeight, without reading the image again.
+// bitsPerChannel is clamped to the range [1, 6]. The vector takes 8 bytes
+// per bin, 2 MiB at 6 bits per channel, which would grow to 128 MiB at 8.
+func FindWeightHistogram(img image.Image, nClusters, bitsPerChannel int, opts ...Option) ([]Color, []float64) {
+	if bitsPerChannel < 1 {
+		bitsPerChannel = 1
+	}
+	if bitsPerChannel > maxHistogramBits {
+		bitsPerChannel = maxHistogramBits
+	}
+	vec := make([]float64, 1<<(3*bitsPerChannel))
+	if nClusters <= 0 {
+		nClusters = nClustersDefault
+	}
+	if ValidateInput(img, nClusters) != nil {
+		return []Color{}, vec
+	}
+	clusters, points, totalWeight := findClusters(img, nClusters, newOptions(opts))
+	shift := 8 - bitsPerChannel
+	var total float64
+	for _, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		r, g, b := int(p.v[0])>>shift, int(p.v[1])>>shift, int(p.v[2])>>shift
+		vec[r<<(2*bitsPerChannel)|g<<bitsPerChannel|b] += p.weight
+		total += p.weight
+	}
+	if total > 0 {
+		for i := range vec {
+			vec[i] /= total
+		}
+	}
+	return clusterColors(clusters, totalWeight), vec
+}
+
+// histogramPoints merges points with identical features into a single
+// point carrying their total weight. Clustering the merged points gives the
+// same result as clustering the original ones, but is much faster for
+// images with few distinct colors. Points are returned in the order of
+// their first occurrence to keep the results deterministic.
+func histogramPoints(points []point) []point {
+	index := make(map[vector]int)
+	var merged []point
+	for _, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		if i, ok := index[p.v]; ok {
+			merged[i].weight += p.weight
+			continue
+		}
+		index[p.v] = len(merged)
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// The following functions compare histograms returned by Histogram with
+// the same bitsPerChannel. Counts are normalized so that each histogram
+// sums to 1, so images of different sizes can be compared. Bins are
+// matched by color only: unlike MatchPalettes, similar colors in
+// different bins count as different. All return 0 for identical
+// distributions and 1 for histograms without common bins, or if either
+// histogram is empty.
+
+// ChiSquareDistance returns half the symmetric chi-square distance between
+// the color distributions of histograms a and b.
+func ChiSquareDistance(a, b []BinCount) float64 {
+	var d float64
+	ok := compareHistograms(a, b, func(p, q float64) {
+		if p+q > 0 {
+			d += (p - q) * (p - q) / (p + q)
+		}
+	})
+	if !ok {
+		return 1
+	}
+	return d / 2
+}
+
+// BhattacharyyaDistance returns the Hellinger form of the Bhattacharyya
+// distance between the color distributions of histograms a and b,
+// sqrt(1 - BC), where BC is the Bhattacharyya coefficient.
+func BhattacharyyaDistance(a, b []BinCount) float64 {
+	var bc float64
+	ok := compareHistograms(a, b, func(p, q float64) {
+		bc += math.Sqrt(p * q)
+	})
+	if !ok {
+		return 1
+	}
+	return math.Sqrt(math.Max(0, 1-bc))
+}
+
+// IntersectionDistance returns one minus the intersection of the color
+// distributions of histograms a and b, which is the fraction of the
+// pixels of a whose color would need to change to match b.
+func IntersectionDistance(a, b []BinCount) float64 {
+	var intersection float64
+	ok := compareHistograms(a, b, func(p, q float64) {
+		intersection += math.Min(p, q)
+	})
+	if !ok {
+		return 1
+	}
+	return math.Max(0, 1-intersection)
+}
+
+// compareHistograms calls f with the normalized counts of each color of
+// histograms a and b, which are 0 for colors missing from a histogram. It
+// returns false without calling f if either histogram has no weight.
+func compareHistograms(a, b []BinCount, f func(p, q float64)) bool {
+	var totalA, totalB float64
+	for _, bin := range a {
+		totalA += bin.Count
+	}
+	for _, bin := range b {
+		totalB += bin.Count
+	}
+	if totalA == 0 || totalB == 0 {
+		return false
+	}
+	counts := make(map[color.RGBA][2]float64, len(a)+len(b))
+	var order []color.RGBA
+	add := func(bins []BinCount, i int, total float64) {
+		for _, bin := range bins {
+			c, ok := counts[bin.RGBA]
+			if !ok {
+				order = append(order, bin.RGBA)
+			}
+			c[i] += bin.Count / total
+			counts[bin.RGBA] = c
+		}
+	}
+	add(a, 0, totalA)
+	add(b, 1, totalB)
+	for _, c := range order {
+		f(counts[c][0], counts[c][1])
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package dominantcolor
+
+import "math"
+
+// CircularHueMean returns the weighted mean of hues in degrees, and their
+// circular variance, from 0 when all hues are equal to 1 when they are
+// spread evenly around the color wheel. Hues are averaged as directions,
+// so that the mean of 350° and 10° is 0° and not 180°. If weights is nil,
+// hues have equal weights. It returns a zero mean and a variance of 1 if
+// the hues cancel out or have no weight.
+func CircularHueMean(hues, weights []float64) (mean, variance float64) {
+	var s hueStats
+	for i, h := range hues {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		s.add(h, w)
+	}
+	return s.mean()
+}
+
+// hueStats accumulates hues as unit vectors.
+type hueStats struct {
+	x, y, weight float64
+}
+
+func (s *hueStats) add(h, weight float64) {
+	h *= math.Pi / 180
+	s.x += weight * math.Cos(h)
+	s.y += weight * math.Sin(h)
+	s.weight += weight
+}
+
+// mean returns the circular mean and variance of the hues.
+func (s *hueStats) mean() (mean, variance float64) {
+	if s.weight <= 0 {
+		return 0, 1
+	}
+	r := math.Hypot(s.x, s.y) / s.weight
+	if r < 1e-12 {
+		return 0, 1
+	}
+	mean = normalizeHue(math.Atan2(s.y, s.x) * 180 / math.Pi)
+	// Tiny negative angles round to 360.
+	if mean >= 360 {
+		mean = 0
+	}
+	return mean, math.Max(0, 1-r)
+}
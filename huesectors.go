@@ -0,0 +1,115 @@
+package dominantcolor
+
+import "sort"
+
+// Algorithm selects how the colors of an image are grouped.
+type Algorithm int
+
+const (
+	// KMean groups colors with the KMean clustering algorithm ported from
+	// Chromium's original color analysis. It is the default.
+	KMean Algorithm = iota
+
+	// HueSectors groups colors by their hue, in the spirit of the hue based
+	// theme color extraction that newer versions of Chromium use instead of
+	// KMean. The color wheel is divided into 12 sectors of 30°, one of
+	// them centered on the circular mean of the heaviest range of hues of
+	// the image so that its main hue, such as reds around 0°, is not split
+	// in two. Colors too unsaturated to have a clear hue are grouped by
+	// lightness into dark, medium and light neutrals. Each group is
+	// represented by the mean color of its pixels. It is much faster than
+	// KMean and keeps distinct hues apart, but does not separate shades of
+	// the same hue. Pinned colors are ignored.
+	HueSectors
+
+	// KMedoids groups colors with the PAM (Partitioning Around Medoids)
+	// k-medoids clustering algorithm, whose centers are always colors of
+	// the image. Each group is represented by the color with the smallest
+	// total distance to the others, which, unlike the mean color, is not
+	// dragged by a few outlier pixels such as dead pixels or watermarks.
+	// Images with more than 1024 distinct colors are clustered from a
+	// weighted sample of 1024 of them. It is slower than KMean. Pinned
+	// colors and the color space are ignored.
+	KMedoids
+)
+
+const (
+	nHueSectors     = 12
+	nNeutralSectors = 3
+
+	// Number of bins of the hue histogram used to align the sectors.
+	nHueBins = 72
+)
+
+// hueSectorClusters groups the points by hue sector and returns the
+// heaviest nCluster groups as clusters.
+func hueSectorClusters(points []point, nCluster int) kMeanClusterGroup {
+	// Sector of each neutral point, from nHueSectors on, or -1 for points
+	// with a clear hue, whose sector is only known once the sectors are
+	// aligned. Hue of the points with a clear hue. Points with zero weight
+	// are left at 0 and skipped.
+	sectorOf := make([]int, len(points))
+	hues := make([]float64, len(points))
+	var bins [nHueBins]float64
+	for i, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		h, s, l := hsl(p.v.RGBA())
+		if s < neutralSaturation || l < 0.05 || l > 0.95 {
+			sectorOf[i] = nHueSectors + int(l*nNeutralSectors*0.999)
+			continue
+		}
+		sectorOf[i], hues[i] = -1, h
+		bins[int(h/(360/nHueBins))%nHueBins] += p.weight
+	}
+	// Find the heaviest range of hues of the size of a sector, and start
+	// the first sector half a sector before the mean hue of the range.
+	const binsPerSector = nHueBins / nHueSectors
+	heaviest, heaviestWeight := 0, 0.0
+	for i := range bins {
+		var w float64
+		for j := 0; j < binsPerSector; j++ {
+			w += bins[(i+j)%nHueBins]
+		}
+		if w > heaviestWeight {
+			heaviest, heaviestWeight = i, w
+		}
+	}
+	var stats hueStats
+	for i, p := range points {
+		if sectorOf[i] >= 0 || p.weight == 0 {
+			continue
+		}
+		if bin := int(hues[i]/(360/nHueBins)) % nHueBins; (bin-heaviest+nHueBins)%nHueBins < binsPerSector {
+			stats.add(hues[i], p.weight)
+		}
+	}
+	mean, _ := stats.mean()
+	start := mean - 360/nHueSectors/2
+	var sectors [nHueSectors + nNeutralSectors]kMeanCluster
+	for i, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		sector := sectorOf[i]
+		if sector < 0 {
+			sector = int(normalizeHue(hues[i]-start)/(360/nHueSectors)) % nHueSectors
+		}
+		sectors[sector].AddPoint(p.v, p.weight)
+	}
+	clusters := make(kMeanClusterGroup, 0, len(sectors))
+	for i := range sectors {
+		c := &sectors[i]
+		if c.counter == 0 {
+			continue
+		}
+		c.RecomputeCentroid()
+		clusters = append(clusters, c)
+	}
+	sort.Stable(byWeight(clusters))
+	if len(clusters) > nCluster {
+		clusters = clusters[:nCluster]
+	}
+	return clusters
+}
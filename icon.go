@@ -0,0 +1,40 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// FindIcon returns the dominant color of a multi-resolution icon set, such
+// as the images of a decoded ICO file, for theming browser tabs and
+// bookmarks. It analyzes the largest image, which has the most detail.
+// Invalid images are skipped.
+func FindIcon(icons []image.Image, opts ...Option) color.RGBA {
+	var largest image.Image
+	var largestArea int
+	for _, img := range icons {
+		if ValidateInput(img, 0) != nil {
+			continue
+		}
+		if area := img.Bounds().Dx() * img.Bounds().Dy(); area > largestArea {
+			largest, largestArea = img, area
+		}
+	}
+	if largest == nil {
+		return color.RGBA{}
+	}
+	return Find(largest, opts...)
+}
+
+// FindIconMerged is like FindIcon but merges the colors of all images of
+// the icon set weighted by their area, so that the small sizes, which are
+// often drawn separately with simplified shapes and stronger colors, also
+// contribute. Images larger than the working size count as if they were
+// resized to it.
+func FindIconMerged(icons []image.Image, opts ...Option) color.RGBA {
+	a := NewAccumulator(opts...)
+	for _, img := range icons {
+		a.Add(img)
+	}
+	return a.o.pick(a.Palette(nClustersDefault))
+}
@@ -0,0 +1,66 @@
+package dominantcolor
+
+import (
+	"image/color"
+	"math"
+)
+
+// Colors with a lower chroma have no meaningful hue when interpolating.
+const achromaticChroma = 1
+
+// Interpolate returns the color at position t between a and b, where 0
+// returns a and 1 returns b. Colors are blended in CIE LCh space along the
+// shortest way around the hue circle, so intermediate colors change
+// uniformly in perceived lightness and hue instead of passing through the
+// muddy colors of an RGB blend. t is clamped to [0, 1].
+func Interpolate(a, b color.RGBA, t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	ca := rgbToLab(a.R, a.G, a.B).LCh()
+	cb := rgbToLab(b.R, b.G, b.B).LCh()
+	// Grays take the hue of the other color so the hue does not swing.
+	if ca.C < achromaticChroma {
+		ca.H = cb.H
+	}
+	if cb.C < achromaticChroma {
+		cb.H = ca.H
+	}
+	dh := cb.H - ca.H
+	if dh > 180 {
+		dh -= 360
+	} else if dh < -180 {
+		dh += 360
+	}
+	c := lch{
+		L: ca.L + t*(cb.L-ca.L),
+		C: ca.C + t*(cb.C-ca.C),
+		H: ca.H + t*dh,
+	}
+	return c.Lab().RGBA()
+}
+
+// Steps returns n colors evenly spaced along a gradient passing through
+// the colors of palette in order, starting with the first color and ending
+// with the last one. Weights are ignored. It returns nil if palette is
+// empty or n is not positive.
+func Steps(palette []Color, n int) []color.RGBA {
+	if len(palette) == 0 || n <= 0 {
+		return nil
+	}
+	steps := make([]color.RGBA, n)
+	if len(palette) == 1 || n == 1 {
+		for i := range steps {
+			steps[i] = palette[0].RGBA
+		}
+		return steps
+	}
+	segments := float64(len(palette) - 1)
+	for i := range steps {
+		pos := float64(i) / float64(n-1) * segments
+		j := int(pos)
+		if j >= len(palette)-1 {
+			j = len(palette) - 2
+		}
+		steps[i] = Interpolate(palette[j].RGBA, palette[j+1].RGBA, pos-float64(j))
+	}
+	return steps
+}
@@ -4,33 +4,53 @@
 
 package dominantcolor
 
-import "math"
+import (
+	"math"
+	"sort"
+)
+
+// Number of features of a point. The first three are the color channels,
+// the remaining ones are the (optionally weighted) pixel coordinates.
+const nFeatures = 5
+
+type vector [nFeatures]float64
+
+// point is a single observation fed to the clustering.
+type point struct {
+	v vector
+
+	// Contribution of the point to the cluster it is assigned to.
+	// Points with zero weight are ignored.
+	weight float64
+}
 
 type kMeanCluster struct {
-	centroid [3]uint8
+	centroid vector
 
 	// Holds the sum of all the points that make up this cluster. Used to
 	// generate the next centroid as well as to check for convergence.
-	aggregate [3]uint32
-	counter   uint32
+	aggregate vector
+	counter   float64
 
 	// The weight of the cluster, determined by how many points were used
 	// to generate the previous centroid.
-	weight uint32
+	weight float64
+
+	// Whether the color of the centroid is fixed. Only the other features
+	// of a pinned centroid move.
+	pinned bool
 }
 
-func (k *kMeanCluster) SetCentroid(r uint8, g uint8, b uint8) {
-	k.centroid[0] = r
-	k.centroid[1] = g
-	k.centroid[2] = b
+func (k *kMeanCluster) SetCentroid(v vector) {
+	k.centroid = v
 }
 
 func (k *kMeanCluster) Centroid() (r, g, b uint8) {
-	return k.centroid[0], k.centroid[1], k.centroid[2]
+	return uint8(k.centroid[0]), uint8(k.centroid[1]), uint8(k.centroid[2])
 }
 
-func (k *kMeanCluster) IsAtCentroid(r uint8, g uint8, b uint8) bool {
-	return r == k.centroid[0] && g == k.centroid[1] && b == k.centroid[2]
+func (k *kMeanCluster) IsAtCentroid(v vector) bool {
+	return v == k.centroid
 }
 
 // Recomputes the centroid of the cluster based on the aggregate data. The
@@ -39,32 +59,46 @@ func (k *kMeanCluster) IsAtCentroid(r uint8, g uint8, b uint8) bool {
 // next iteration.
 func (k *kMeanCluster) RecomputeCentroid() {
 	if k.counter > 0 {
-		k.centroid[0] = uint8(k.aggregate[0] / k.counter)
-		k.centroid[1] = uint8(k.aggregate[1] / k.counter)
-		k.centroid[2] = uint8(k.aggregate[2] / k.counter)
-
-		k.aggregate[0] = 0
-		k.aggregate[1] = 0
-		k.aggregate[2] = 0
+		for i := range k.centroid {
+			k.centroid[i] = k.mean(i)
+		}
+		k.aggregate = vector{}
 		k.weight = k.counter
 		k.counter = 0
 	}
 }
 
-func (k *kMeanCluster) AddPoint(r uint8, g uint8, b uint8) {
-	k.aggregate[0] += uint32(r)
-	k.aggregate[1] += uint32(g)
-	k.aggregate[2] += uint32(b)
-	k.counter++
+// mean returns the value of feature i in the next centroid. Color
+// channels are truncated to integers so that the centroid always
+// represents a real RGB color, and are left unchanged if the centroid is
+// pinned.
+func (k *kMeanCluster) mean(i int) float64 {
+	if k.pinned && i < 3 {
+		return k.centroid[i]
+	}
+	m := k.aggregate[i] / k.counter
+	if i < 3 {
+		m = math.Floor(m)
+	}
+	return m
+}
+
+func (k *kMeanCluster) AddPoint(v vector, weight float64) {
+	for i := range v {
+		k.aggregate[i] += v[i] * weight
+	}
+	k.counter += weight
 }
 
 // Just returns the distance^2. Since we are comparing relative distances
 // there is no need to perform the expensive sqrt() operation.
-func (k *kMeanCluster) GetDistanceSqr(r uint8, g uint8, b uint8) uint32 {
-	dr := uint32(r) - uint32(k.centroid[0])
-	dg := uint32(g) - uint32(k.centroid[1])
-	db := uint32(b) - uint32(k.centroid[2])
-	return dr*dr + dg*dg + db*db
+func (k *kMeanCluster) GetDistanceSqr(v vector) float64 {
+	var d float64
+	for i := range v {
+		di := v[i] - k.centroid[i]
+		d += di * di
+	}
+	return d
 }
 
 // In order to determine if we have hit convergence or not we need to see
@@ -75,27 +109,30 @@ func (k *kMeanCluster) CompareCentroidWithAggregate() bool {
 	if k.counter == 0 {
 		return false
 	}
-	return uint8(k.aggregate[0]/uint32(k.counter)) == k.centroid[0] &&
-		uint8(k.aggregate[1]/uint32(k.counter)) == k.centroid[1] &&
-		uint8(k.aggregate[2]/uint32(k.counter)) == k.centroid[2]
+	for i := range k.centroid {
+		if k.mean(i) != k.centroid[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type kMeanClusterGroup []*kMeanCluster
 
-func (a kMeanClusterGroup) ContainsCentroid(r, g, b uint8) bool {
+func (a kMeanClusterGroup) ContainsCentroid(v vector) bool {
 	for _, c := range a {
-		if c.IsAtCentroid(r, g, b) {
+		if c.IsAtCentroid(v) {
 			return true
 		}
 	}
 	return false
 }
 
-func (a kMeanClusterGroup) Closest(r, g, b uint8) *kMeanCluster {
+func (a kMeanClusterGroup) Closest(v vector) *kMeanCluster {
 	var closest *kMeanCluster
-	var distanceToClosest uint32 = math.MaxUint32
+	distanceToClosest := math.Inf(1)
 	for _, c := range a {
-		d := c.GetDistanceSqr(r, g, b)
+		d := c.GetDistanceSqr(v)
 		if d < distanceToClosest {
 			distanceToClosest = d
 			closest = c
@@ -104,6 +141,85 @@ func (a kMeanClusterGroup) Closest(r, g, b uint8) *kMeanCluster {
 	return closest
 }
 
+// Step assigns each point to the closest cluster and moves the centroids
+// of the clusters to the mean of their points. It returns whether the
+// centroids have converged.
+func (a kMeanClusterGroup) Step(points []point) bool {
+	centroids := a.flatCentroids()
+	for i := range points {
+		p := &points[i]
+		// Ignore transparent pixels.
+		if p.weight == 0 {
+			continue
+		}
+		// Figure out which cluster this color is closest to in RGB space.
+		closest := a[closestCentroid(centroids, &p.v)]
+		closest.AddPoint(p.v, p.weight)
+	}
+	// Calculate the new cluster centers and see if we've converged or not.
+	convergence := true
+	for _, c := range a {
+		convergence = convergence && c.CompareCentroidWithAggregate()
+		c.RecomputeCentroid()
+	}
+	return convergence
+}
+
+// TrimmedStep is like Step but ignores the points farthest from the
+// centroid of each cluster, up to the given fraction of its weight, when
+// moving the centroid. The weight of a cluster still counts all of its
+// points.
+func (a kMeanClusterGroup) TrimmedStep(points []point, trim float64) bool {
+	type member struct {
+		p        *point
+		distance float64
+	}
+	centroids := a.flatCentroids()
+	members := make([][]member, len(a))
+	weights := make([]float64, len(a))
+	for i := range points {
+		p := &points[i]
+		if p.weight == 0 {
+			continue
+		}
+		c := closestCentroid(centroids, &p.v)
+		members[c] = append(members[c], member{p, a[c].GetDistanceSqr(p.v)})
+		weights[c] += p.weight
+	}
+	convergence := true
+	for i, c := range a {
+		m := members[i]
+		sort.SliceStable(m, func(i, j int) bool { return m[i].distance < m[j].distance })
+		// Keep the closest points up to the untrimmed weight, and at
+		// least one.
+		for j, kept := 0, 0.0; j < len(m) && (j == 0 || kept < weights[i]*(1-trim)); j++ {
+			c.AddPoint(m[j].p.v, m[j].p.weight)
+			kept += m[j].p.weight
+		}
+		convergence = convergence && c.CompareCentroidWithAggregate()
+		c.RecomputeCentroid()
+		if len(m) != 0 {
+			c.weight = weights[i]
+		}
+	}
+	return convergence
+}
+
+// weightsSettled returns whether the weight of each cluster, as a fraction
+// of the total weight, differs by less than tolerance from previous.
+func (a kMeanClusterGroup) weightsSettled(previous []float64, tolerance float64) bool {
+	var total float64
+	for _, c := range a {
+		total += c.weight
+	}
+	for i, c := range a {
+		if math.Abs(c.weight-previous[i]) >= tolerance*total {
+			return false
+		}
+	}
+	return true
+}
+
 type byWeight kMeanClusterGroup
 
 func (a byWeight) Len() int           { return len(a) }
@@ -0,0 +1,232 @@
+package dominantcolor
+
+import "math"
+
+// Largest number of distinct colors PAM is run on. Images with more are
+// clustered, as in CLARA, from a weighted sample of their colors, each
+// carrying the weight of the colors closest to it.
+const maxPAMSamples = 1024
+
+// pamClusters groups samples, which are usually distinct colors, into
+// nCluster clusters centered on one of their points with the PAM
+// (Partitioning Around Medoids) algorithm. The BUILD phase picks the
+// medoids one by one, each time the point that most reduces the weighted
+// sum of distances of the points to their closest medoid. The SWAP phase
+// then repeatedly makes the swap of a medoid and another point that most
+// reduces that sum, up to the given number of times, until no swap
+// improves it. Swaps are evaluated as in FastPAM1, from the distances of
+// each point to its closest and second closest medoids.
+func pamClusters(samples []point, nCluster, iterations int, o *options) kMeanClusterGroup {
+	end := o.span(PhaseInit)
+	candidates := pamCandidates(samples)
+	s := newPAMState(candidates)
+	s.build(nCluster)
+	end()
+	defer o.span(PhaseIterations)()
+	for i := 0; i < iterations; i++ {
+		if !s.swap() {
+			break
+		}
+	}
+	clusters := make(kMeanClusterGroup, len(s.medoids))
+	for i, m := range s.medoids {
+		clusters[i] = new(kMeanCluster)
+		clusters[i].SetCentroid(candidates[m].v)
+	}
+	// Weigh the clusters by all the samples closest to them.
+	centroids := clusters.flatCentroids()
+	for _, p := range samples {
+		if p.weight != 0 && len(clusters) != 0 {
+			clusters[closestCentroid(centroids, &p.v)].weight += p.weight
+		}
+	}
+	kept := clusters[:0]
+	for _, c := range clusters {
+		if c.weight > 0 {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// pamCandidates returns the samples with a weight, or at most
+// maxPAMSamples of them picked with probabilities proportional to their
+// weights, each then weighted by the samples closest to it.
+func pamCandidates(samples []point) []point {
+	var candidates []point
+	for _, p := range samples {
+		if p.weight != 0 {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) <= maxPAMSamples {
+		return candidates
+	}
+	candidates = reservoirSample(candidates, maxPAMSamples)
+	group := make(kMeanClusterGroup, len(candidates))
+	for i, c := range candidates {
+		group[i] = &kMeanCluster{centroid: c.v}
+		candidates[i].weight = 0
+	}
+	centroids := group.flatCentroids()
+	for _, p := range samples {
+		if p.weight != 0 {
+			candidates[closestCentroid(centroids, &p.v)].weight += p.weight
+		}
+	}
+	return candidates
+}
+
+// pamState holds the medoids of PAM among points, and the distances of
+// each point to its closest and second closest medoids.
+type pamState struct {
+	points  []point
+	medoids []int
+
+	// Distances between the points, row by row.
+	distances []float64
+
+	// Index in medoids of the medoid closest to each point.
+	nearest []int
+
+	// Distances of each point to its closest and second closest medoids.
+	first, second []float64
+}
+
+func newPAMState(points []point) *pamState {
+	s := &pamState{
+		points:  points,
+		nearest: make([]int, len(points)),
+		first:   make([]float64, len(points)),
+		second:  make([]float64, len(points)),
+	}
+	n := len(points)
+	s.distances = make([]float64, n*n)
+	for i := range points {
+		s.first[i], s.second[i] = math.Inf(1), math.Inf(1)
+		for j := 0; j < i; j++ {
+			var d float64
+			for k := range points[i].v {
+				x := points[i].v[k] - points[j].v[k]
+				d += x * x
+			}
+			s.distances[i*n+j] = math.Sqrt(d)
+			s.distances[j*n+i] = s.distances[i*n+j]
+		}
+	}
+	return s
+}
+
+// distance returns the Euclidean distance between the points i and j.
+func (s *pamState) distance(i, j int) float64 {
+	return s.distances[i*len(s.points)+j]
+}
+
+// build picks nCluster medoids, or as many as there are points, greedily.
+func (s *pamState) build(nCluster int) {
+	isMedoid := make([]bool, len(s.points))
+	for len(s.medoids) < nCluster && len(s.medoids) < len(s.points) {
+		best, bestGain := -1, math.Inf(-1)
+		for c := range s.points {
+			if isMedoid[c] {
+				continue
+			}
+			// Gain of adding c as a medoid. The first medoid minimizes the
+			// sum of distances instead.
+			var gain float64
+			for o, p := range s.points {
+				d := s.distance(o, c)
+				if len(s.medoids) == 0 {
+					gain -= p.weight * d
+				} else if d < s.first[o] {
+					gain += p.weight * (s.first[o] - d)
+				}
+			}
+			if gain > bestGain {
+				best, bestGain = c, gain
+			}
+		}
+		if best < 0 {
+			return
+		}
+		isMedoid[best] = true
+		s.medoids = append(s.medoids, best)
+		s.assign()
+	}
+}
+
+// swap makes the swap of a medoid and another point that most reduces the
+// weighted sum of distances, and reports whether there was one.
+func (s *pamState) swap() bool {
+	k := len(s.medoids)
+	if k == 0 {
+		return false
+	}
+	isMedoid := make([]bool, len(s.points))
+	for _, m := range s.medoids {
+		isMedoid[m] = true
+	}
+	change := make([]float64, k)
+	bestMedoid, bestPoint, bestChange := -1, -1, 0.0
+	for c := range s.points {
+		if isMedoid[c] {
+			continue
+		}
+		// Each point moves to c if it is closer than its closest medoid
+		// left. Only the points of the removed medoid lose their closest
+		// medoid, so the change common to all removals is summed apart.
+		for m := range change {
+			change[m] = 0
+		}
+		var shared float64
+		row := s.distances[c*len(s.points) : (c+1)*len(s.points)]
+		for o, d := range row {
+			w, first, second := s.points[o].weight, s.first[o], s.second[o]
+			switch {
+			case d < first:
+				shared += w * (d - first)
+			case d < second:
+				change[s.nearest[o]] += w * (d - first)
+			default:
+				change[s.nearest[o]] += w * (second - first)
+			}
+		}
+		for m := range change {
+			if total := change[m] + shared; total < bestChange {
+				bestMedoid, bestPoint, bestChange = m, c, total
+			}
+		}
+	}
+	// Ignore changes within rounding errors to stop on a minimum.
+	if bestMedoid < 0 || bestChange > -1e-9*s.totalWeight() {
+		return false
+	}
+	s.medoids[bestMedoid] = bestPoint
+	s.assign()
+	return true
+}
+
+// assign computes the distances of the points to their closest and second
+// closest medoids.
+func (s *pamState) assign() {
+	for o := range s.points {
+		s.first[o], s.second[o] = math.Inf(1), math.Inf(1)
+		for i, m := range s.medoids {
+			switch d := s.distance(o, m); {
+			case d < s.first[o]:
+				s.second[o] = s.first[o]
+				s.first[o], s.nearest[o] = d, i
+			case d < s.second[o]:
+				s.second[o] = d
+			}
+		}
+	}
+}
+
+func (s *pamState) totalWeight() float64 {
+	var total float64
+	for _, p := range s.points {
+		total += p.weight
+	}
+	return total
+}
@@ -0,0 +1,80 @@
+package dominantcolor
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPAMClusters(t *testing.T) {
+	rnd := rand.New(rand.NewSource(0))
+	points := make([]point, 60)
+	for i := range points {
+		for j := 0; j < 3; j++ {
+			points[i].v[j] = float64(rnd.Intn(256))
+		}
+		points[i].weight = float64(1 + rnd.Intn(10))
+	}
+	cost := func(medoids []vector) float64 {
+		var sum float64
+		for _, p := range points {
+			d := math.Inf(1)
+			for _, m := range medoids {
+				var d2 float64
+				for k := range m {
+					d2 += (m[k] - p.v[k]) * (m[k] - p.v[k])
+				}
+				d = math.Min(d, math.Sqrt(d2))
+			}
+			sum += p.weight * d
+		}
+		return sum
+	}
+	clusters := pamClusters(points, 4, nIterations, newOptions(nil))
+	if len(clusters) != 4 {
+		t.Fatalf("Got %d clusters, want 4", len(clusters))
+	}
+	medoids := make([]vector, len(clusters))
+	var total float64
+	for i, c := range clusters {
+		medoids[i] = c.centroid
+		total += c.weight
+	}
+	if total != sumWeights(points) {
+		t.Errorf("Clusters weigh %f, want %f", total, sumWeights(points))
+	}
+	// No swap of a medoid and another point lowers the cost.
+	best := cost(medoids)
+	for i := range medoids {
+		for _, p := range points {
+			swapped := append([]vector(nil), medoids...)
+			swapped[i] = p.v
+			if c := cost(swapped); c < best-1e-9 {
+				t.Fatalf("Swapping medoid %d for %v lowers the cost from %f to %f", i, p.v, best, c)
+			}
+		}
+	}
+}
+
+func TestPAMClusters_Sampled(t *testing.T) {
+	points := make([]point, 0, 4096)
+	for i := 0; i < cap(points); i++ {
+		points = append(points, point{v: vector{float64(i % 256), float64(i / 256 * 16), 0}, weight: 1})
+	}
+	clusters := pamClusters(points, 3, nIterations, newOptions(nil))
+	var total float64
+	for _, c := range clusters {
+		total += c.weight
+	}
+	if len(clusters) != 3 || total != float64(len(points)) {
+		t.Errorf("Unexpected clusters of sampled points: %d weighing %f", len(clusters), total)
+	}
+}
+
+func sumWeights(points []point) float64 {
+	var sum float64
+	for _, p := range points {
+		sum += p.weight
+	}
+	return sum
+}
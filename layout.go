@@ -0,0 +1,204 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Quadrant is one of the four quarters of an image.
+type Quadrant int
+
+const (
+	TopLeft Quadrant = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// quadrants returns the rectangles of the quadrants of r.
+func quadrants(r image.Rectangle) [4]image.Rectangle {
+	mid := image.Pt(r.Min.X+r.Dx()/2, r.Min.Y+r.Dy()/2)
+	return [4]image.Rectangle{
+		TopLeft:     image.Rectangle{Min: r.Min, Max: mid},
+		TopRight:    image.Rect(mid.X, r.Min.Y, r.Max.X, mid.Y),
+		BottomLeft:  image.Rect(r.Min.X, mid.Y, mid.X, r.Max.Y),
+		BottomRight: image.Rectangle{Min: mid, Max: r.Max},
+	}
+}
+
+// FindQuadrants returns the dominant colors with their weights in each
+// quadrant of img, indexed by Quadrant, as FindRegions does. This is a
+// cheap layout-aware signal, for example to choose where to place text
+// over a hero image. Quadrants of images smaller than 2x2 pixels may be
+// empty.
+func FindQuadrants(img image.Image, nClusters int, opts ...Option) [4][]Color {
+	var regions [4]image.Rectangle
+	if !isNil(img) {
+		regions = quadrants(img.Bounds())
+	}
+	var palettes [4][]Color
+	copy(palettes[:], FindRegions(img, regions[:], nClusters, opts...))
+	return palettes
+}
+
+// Fraction of a region above which a color must contrast with text placed
+// over it.
+const textBackgroundCoverage = 0.1
+
+// TextPlacement is a region of an image suggested for overlay text.
+type TextPlacement struct {
+	Quadrant Quadrant
+
+	// Bounds of the quadrant in the coordinates of the image.
+	Region image.Rectangle
+
+	// Dominant color of the region.
+	Background color.RGBA
+
+	// Black or white, whichever contrasts more with the region.
+	Text color.RGBA
+
+	// Lowest WCAG contrast ratio between Text and Background or the colors
+	// covering at least 10% of the region.
+	Contrast float64
+
+	// Weighted variance of the colors of the region in CIE L*a*b*. Lower
+	// values mean a more uniform background.
+	Variance float64
+}
+
+// SuggestTextPlacement returns the quadrant of img with the most uniform
+// background, and the text color that contrasts most with it, to place a
+// caption over a hero image. Ties are broken by the contrast. It returns
+// false if the image is invalid.
+func SuggestTextPlacement(img image.Image, opts ...Option) (TextPlacement, bool) {
+	if ValidateInput(img, 0) != nil {
+		return TextPlacement{}, false
+	}
+	regions := quadrants(img.Bounds())
+	var best TextPlacement
+	found := false
+	for q, palette := range FindQuadrants(img, nClustersDefault, opts...) {
+		palette = Normalize(palette)
+		if len(palette) == 0 {
+			continue
+		}
+		p := TextPlacement{
+			Quadrant:   Quadrant(q),
+			Region:     regions[q],
+			Background: palette[0].RGBA,
+			Variance:   labVariance(palette),
+		}
+		for _, text := range []color.RGBA{{A: 0xff}, {R: 0xff, G: 0xff, B: 0xff, A: 0xff}} {
+			contrast := math.Inf(1)
+			for i, c := range palette {
+				if i == 0 || c.Weight >= textBackgroundCoverage {
+					contrast = math.Min(contrast, ContrastRatio(text, c.RGBA))
+				}
+			}
+			if contrast > p.Contrast {
+				p.Text, p.Contrast = text, contrast
+			}
+		}
+		if !found || p.Variance < best.Variance || p.Variance == best.Variance && p.Contrast > best.Contrast {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// labVariance returns the variance of the colors of a palette with
+// normalized weights in CIE L*a*b*.
+func labVariance(palette []Color) float64 {
+	labs := make([]lab, len(palette))
+	var mean lab
+	for i, c := range palette {
+		labs[i] = rgbToLab(c.R, c.G, c.B)
+		mean.L += labs[i].L * c.Weight
+		mean.A += labs[i].A * c.Weight
+		mean.B += labs[i].B * c.Weight
+	}
+	var variance float64
+	for i, c := range palette {
+		d := deltaE(labs[i], mean)
+		variance += c.Weight * d * d
+	}
+	return variance
+}
+
+// Thickness of the strips analyzed by EdgeColors, as a fraction of the
+// size of the image.
+const edgeStrip = 0.05
+
+// Edges holds a color for each edge of an image.
+type Edges struct {
+	Top, Bottom, Left, Right color.RGBA
+}
+
+// EdgeColors returns the heaviest color along each edge of img, in strips
+// 5% of the image thick, so that web pages can extend the image into the
+// surrounding background seamlessly. Unlike Find, colors are not skipped
+// for being too bright or too dark. Edges of invalid images are
+// transparent.
+func EdgeColors(img image.Image, opts ...Option) Edges {
+	if ValidateInput(img, 0) != nil {
+		return Edges{}
+	}
+	r := img.Bounds()
+	dx := int(math.Ceil(float64(r.Dx()) * edgeStrip))
+	dy := int(math.Ceil(float64(r.Dy()) * edgeStrip))
+	strips := []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+dy),
+		image.Rect(r.Min.X, r.Max.Y-dy, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+dx, r.Max.Y),
+		image.Rect(r.Max.X-dx, r.Min.Y, r.Max.X, r.Max.Y),
+	}
+	// Start from the heaviest colors, so that thin strips of few colors are
+	// separated reliably.
+	opts = append([]Option{func(o *options) { o.exact = true }}, opts...)
+	var colors [4]color.RGBA
+	for i, palette := range FindRegions(img, strips, nClustersDefault, opts...) {
+		if len(palette) > 0 {
+			colors[i] = palette[0].RGBA
+		}
+	}
+	return Edges{Top: colors[0], Bottom: colors[1], Left: colors[2], Right: colors[3]}
+}
+
+// FindBorderColor returns a color to frame a thumbnail of img shown over a
+// page background: the dominant color of img, made lighter or darker in
+// CIE LCh by as little as needed for a contrast ratio of at least minRatio
+// against both page and the colors along the edges of img, so that the
+// frame stands out from both. WCAG requires a ratio of at least 3 for
+// such graphical objects. It returns false if no lightness reaches the
+// ratio or the image is invalid.
+func FindBorderColor(img image.Image, page color.RGBA, minRatio float64, opts ...Option) (color.RGBA, bool) {
+	if ValidateInput(img, 0) != nil {
+		return color.RGBA{}, false
+	}
+	edges := EdgeColors(img, opts...)
+	against := []color.RGBA{page, edges.Top, edges.Bottom, edges.Left, edges.Right}
+	contrasts := func(c color.RGBA) bool {
+		for _, a := range against {
+			if ContrastRatio(c, a) < minRatio {
+				return false
+			}
+		}
+		return true
+	}
+	base := Find(img, opts...)
+	c := rgbToLab(base.R, base.G, base.B).LCh()
+	for d := 0.0; d <= 100; d++ {
+		for _, l := range []float64{c.L - d, c.L + d} {
+			if l < 0 || l > 100 {
+				continue
+			}
+			adjusted := lch{L: l, C: c.C, H: c.H}.Lab().RGBA()
+			if contrasts(adjusted) {
+				return adjusted, true
+			}
+		}
+	}
+	return color.RGBA{}, false
+}
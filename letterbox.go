@@ -0,0 +1,171 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	// Largest difference of a channel from the color of the bars of a
+	// pixel that is part of a bar, to tolerate compression noise.
+	letterboxTolerance = 24
+
+	// Number of pixels sampled along each row or column.
+	letterboxSamples = 256
+)
+
+// Letterbox describes the uniform bars around the content of an image,
+// such as the black bars of a video frame of a different aspect ratio.
+type Letterbox struct {
+	// Thickness of the bars on each side in pixels. Zero means no bar.
+	Top, Bottom, Left, Right int
+
+	// Mean color of the bars.
+	Color color.RGBA
+}
+
+// Content returns the bounds of the content inside the bars of an image
+// with the given bounds.
+func (l Letterbox) Content(bounds image.Rectangle) image.Rectangle {
+	return image.Rect(bounds.Min.X+l.Left, bounds.Min.Y+l.Top, bounds.Max.X-l.Right, bounds.Max.Y-l.Bottom)
+}
+
+// DetectLetterbox detects uniform horizontal and vertical bars around the
+// content of img, so that video thumbnail pipelines can crop them, with
+// Letterbox.Content, or blend them before further processing. Bars must
+// cover at least one corner of the image and be at least 1% of its size
+// thick. It returns false if there are no bars, or if the whole image is
+// uniform.
+func DetectLetterbox(img image.Image) (Letterbox, bool) {
+	if ValidateInput(img, 0) != nil {
+		return Letterbox{}, false
+	}
+	r := img.Bounds()
+	corners := []image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, {r.Max.X - 1, r.Max.Y - 1}}
+	var best Letterbox
+	bestArea := 0
+	for _, corner := range corners {
+		ref := opaqueAt(img, corner.X, corner.Y)
+		l := Letterbox{
+			Top:    countUniform(img, ref, r.Min.Y, 1, r.Dy(), true),
+			Bottom: countUniform(img, ref, r.Max.Y-1, -1, r.Dy(), true),
+			Left:   countUniform(img, ref, r.Min.X, 1, r.Dx(), false),
+			Right:  countUniform(img, ref, r.Max.X-1, -1, r.Dx(), false),
+		}
+		// A uniform image has no content to box.
+		if l.Top == r.Dy() || l.Left == r.Dx() {
+			return Letterbox{}, false
+		}
+		l.Top = minThickness(l.Top, r.Dy())
+		l.Bottom = minThickness(l.Bottom, r.Dy())
+		l.Left = minThickness(l.Left, r.Dx())
+		l.Right = minThickness(l.Right, r.Dx())
+		if area := r.Dx()*r.Dy() - l.Content(r).Dx()*l.Content(r).Dy(); area > bestArea {
+			l.Color = ref
+			best, bestArea = l, area
+		}
+	}
+	if bestArea == 0 {
+		return Letterbox{}, false
+	}
+	best.Color = barColor(img, best)
+	return best, true
+}
+
+// countUniform returns the number of consecutive rows, or columns, of img
+// matching ref, starting at start and moving by step, out of n. Lines are
+// first checked with a stride to find the end of the bar quickly, then
+// every line before it is checked, since the stride may step over a line
+// of content. Lines matching at every stride across the whole image are
+// taken as uniform without checking the others.
+func countUniform(img image.Image, ref color.RGBA, start, step, n int, rows bool) int {
+	r := img.Bounds()
+	matches := func(i int) bool {
+		line := start + i*step
+		if rows {
+			return lineMatches(img, ref, image.Pt(r.Min.X, line), image.Pt(1, 0), r.Dx())
+		}
+		return lineMatches(img, ref, image.Pt(line, r.Min.Y), image.Pt(0, 1), r.Dy())
+	}
+	stride := (n + letterboxSamples - 1) / letterboxSamples
+	i := 0
+	for i < n && matches(i) {
+		i += stride
+	}
+	if i >= n && matches(n-1) {
+		return n
+	}
+	if i > n {
+		i = n
+	}
+	for j := 0; j < i; j++ {
+		if !matches(j) {
+			return j
+		}
+	}
+	return i
+}
+
+// lineMatches returns whether the n pixels starting at p in direction d
+// all match ref, sampling at most letterboxSamples of them.
+func lineMatches(img image.Image, ref color.RGBA, p, d image.Point, n int) bool {
+	stride := (n + letterboxSamples - 1) / letterboxSamples
+	for i := 0; i < n; i += stride {
+		c := opaqueAt(img, p.X+d.X*i, p.Y+d.Y*i)
+		if channelDiff(c.R, ref.R) > letterboxTolerance ||
+			channelDiff(c.G, ref.G) > letterboxTolerance ||
+			channelDiff(c.B, ref.B) > letterboxTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// barColor returns the mean color of the sampled pixels of the bars of
+// img.
+func barColor(img image.Image, l Letterbox) color.RGBA {
+	r := img.Bounds()
+	content := l.Content(r)
+	var sum [3]float64
+	var n float64
+	strideX := (r.Dx() + letterboxSamples - 1) / letterboxSamples
+	strideY := (r.Dy() + letterboxSamples - 1) / letterboxSamples
+	for y := r.Min.Y; y < r.Max.Y; y += strideY {
+		for x := r.Min.X; x < r.Max.X; x += strideX {
+			if image.Pt(x, y).In(content) {
+				continue
+			}
+			c := opaqueAt(img, x, y)
+			sum[0] += float64(c.R)
+			sum[1] += float64(c.G)
+			sum[2] += float64(c.B)
+			n++
+		}
+	}
+	if n == 0 {
+		return l.Color
+	}
+	return color.RGBA{R: clampChannel(sum[0] / n), G: clampChannel(sum[1] / n), B: clampChannel(sum[2] / n), A: 0xff}
+}
+
+// opaqueAt returns the color of the pixel at (x, y) ignoring its alpha.
+func opaqueAt(img image.Image, x, y int) color.RGBA {
+	r, g, b, _ := rgbaAt(img, x, y)
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
+
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// minThickness returns thickness if it is at least 1% of size, and zero
+// otherwise.
+func minThickness(thickness, size int) int {
+	if thickness*100 < size {
+		return 0
+	}
+	return thickness
+}
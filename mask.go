@@ -0,0 +1,118 @@
+package dominantcolor
+
+import "image"
+
+// Masker selects the pixels of an image to analyze, such as the subject
+// of a photo found by an external segmentation model.
+type Masker interface {
+	// Mask returns the weight of each pixel of img as its alpha value.
+	// Pixels with zero alpha are ignored. The mask is scaled to the bounds
+	// of img if they differ. A nil mask selects all pixels.
+	Mask(img image.Image) *image.Alpha
+}
+
+// MaskerFunc is an adapter to allow the use of ordinary functions as
+// Maskers.
+type MaskerFunc func(img image.Image) *image.Alpha
+
+// Mask returns f(img).
+func (f MaskerFunc) Mask(img image.Image) *image.Alpha {
+	return f(img)
+}
+
+// weightMask maps pixels of an image with the given bounds to the pixels of
+// the original image and weighs them by the configured mask and regions.
+type weightMask struct {
+	// Mask computed for the original image, may be nil.
+	alpha *image.Alpha
+
+	// Regions of the original image to include or exclude.
+	include, exclude []image.Rectangle
+
+	// Weights of the pixels of the original image in row-major order, may
+	// be nil.
+	weights []float64
+
+	// Bounds of the original image.
+	src image.Rectangle
+
+	// Bounds of the pixels being weighed.
+	bounds image.Rectangle
+}
+
+// newWeightMask computes the mask of img with the configured Masker and
+// regions. It returns nil if all pixels are selected.
+func newWeightMask(img image.Image, o *options) *weightMask {
+	m := &weightMask{
+		include: o.includeRegions,
+		exclude: o.excludeRegions,
+		weights: o.pixelWeights,
+		src:     img.Bounds(),
+		bounds:  img.Bounds(),
+	}
+	if o.masker != nil {
+		if alpha := o.masker.Mask(img); alpha != nil && !alpha.Bounds().Empty() {
+			m.alpha = alpha
+		}
+	}
+	if m.alpha == nil && len(m.include) == 0 && len(m.exclude) == 0 && m.weights == nil {
+		return nil
+	}
+	return m
+}
+
+// scaled returns the mask for a resized version of the image with the
+// given bounds.
+func (m *weightMask) scaled(bounds image.Rectangle) *weightMask {
+	if m == nil {
+		return nil
+	}
+	scaled := *m
+	scaled.bounds = bounds
+	return &scaled
+}
+
+// weight returns the weight of the pixel at (x, y), between 0 and 1 unless
+// pixel weights are set.
+func (m *weightMask) weight(x, y int) float64 {
+	if m == nil {
+		return 1
+	}
+	p := mapPoint(image.Pt(x, y), m.bounds, m.src)
+	if len(m.include) > 0 && !inAny(p, m.include) {
+		return 0
+	}
+	if inAny(p, m.exclude) {
+		return 0
+	}
+	w := 1.0
+	if m.weights != nil {
+		w = m.weights[(p.Y-m.src.Min.Y)*m.src.Dx()+p.X-m.src.Min.X]
+	}
+	if m.alpha == nil {
+		return w
+	}
+	p = mapPoint(p, m.src, m.alpha.Bounds())
+	return w * float64(m.alpha.AlphaAt(p.X, p.Y).A) / 0xff
+}
+
+// mapPoint maps p from the rectangle from to the rectangle to by sampling
+// the pixel centers, in the same way as the nearest neighbor resize.
+func mapPoint(p image.Point, from, to image.Rectangle) image.Point {
+	if from == to {
+		return p
+	}
+	return image.Point{
+		X: to.Min.X + (2*(p.X-from.Min.X)+1)*to.Dx()/(2*from.Dx()),
+		Y: to.Min.Y + (2*(p.Y-from.Min.Y)+1)*to.Dy()/(2*from.Dy()),
+	}
+}
+
+func inAny(p image.Point, rects []image.Rectangle) bool {
+	for _, r := range rects {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
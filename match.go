@@ -0,0 +1,143 @@
+package dominantcolor
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Pair is a pair of matching colors from two palettes.
+type Pair struct {
+	// Indexes of the colors in the first and second palette.
+	IndexA, IndexB int
+	// Color difference between the colors.
+	DeltaE float64
+}
+
+// DeltaE returns the CIE76 color difference between a and b, which is the
+// Euclidean distance in CIE L*a*b* space. A difference of about 2.3 is
+// just noticeable.
+func DeltaE(a, b color.RGBA) float64 {
+	return deltaE(rgbToLab(a.R, a.G, a.B), rgbToLab(b.R, b.G, b.B))
+}
+
+// MatchPalettes pairs the colors of palettes a and b so that the sum of the
+// color differences of the pairs is minimal. If the palettes differ in
+// length, the extra colors of the longer one are left unpaired. Pairs are
+// returned in the order of the colors in a.
+func MatchPalettes(a, b []Color) []Pair {
+	swapped := len(a) > len(b)
+	if swapped {
+		a, b = b, a
+	}
+	cost := make([][]float64, len(a))
+	for i := range a {
+		cost[i] = make([]float64, len(b))
+		for j := range b {
+			cost[i][j] = DeltaE(a[i].RGBA, b[j].RGBA)
+		}
+	}
+	assignment := hungarian(cost)
+	pairs := make([]Pair, 0, len(a))
+	for i, j := range assignment {
+		p := Pair{IndexA: i, IndexB: j, DeltaE: cost[i][j]}
+		if swapped {
+			p.IndexA, p.IndexB = p.IndexB, p.IndexA
+		}
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].IndexA < pairs[j].IndexA })
+	return pairs
+}
+
+// PaletteDistance returns the mean color difference between the matching
+// colors of palettes a and b, as paired by MatchPalettes, weighted by the
+// mean weight of each pair. Weights are normalized in each palette. It
+// returns 0 for two empty palettes and +Inf if only one is empty. It can
+// be used as a similarity measure between images: palettes of the same
+// scene typically differ by less than 5.
+func PaletteDistance(a, b []Color) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == len(b) {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	a, b = Normalize(a), Normalize(b)
+	var sum, total float64
+	for _, p := range MatchPalettes(a, b) {
+		w := (a[p.IndexA].Weight + b[p.IndexB].Weight) / 2
+		sum += p.DeltaE * w
+		total += w
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// hungarian solves the assignment problem for an n by m cost matrix with
+// n <= m and returns the column assigned to each row.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+	// Potentials of rows and columns, and the row matched to each column.
+	// Index 0 is a virtual row and column.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	match := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := match[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if match[j0] == 0 {
+				break
+			}
+		}
+		// Follow the augmenting path back to the virtual column.
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if match[j] != 0 {
+			assignment[match[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,65 @@
+package dominantcolor
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Smallest working image size chosen to satisfy a memory budget. Below
+// this size the results are no longer meaningful.
+const minWorkingSize = 16
+
+// Approximate number of bytes allocated per pixel of the working image.
+const (
+	// Resized image plus its clustering point.
+	bytesPerPixel = 4 + int64(unsafe.Sizeof(point{}))
+	// Distinct color entry in the histogram, including map overhead.
+	bytesPerHistogramEntry = int64(unsafe.Sizeof(point{})) + 2*int64(unsafe.Sizeof(vector{}))
+	// Lab color, label and distance of each pixel for superpixels.
+	bytesPerSuperpixelPixel = int64(unsafe.Sizeof(lab{})) + 16
+	// Label, visited flag and flood fill stack for region weights.
+	bytesPerRegionPixel = 17
+)
+
+// memoryEstimate returns the approximate number of bytes allocated while
+// clustering n pixels.
+func (o *options) memoryEstimate(n int, histogram bool) int64 {
+	perPixel := bytesPerPixel
+	if histogram {
+		perPixel += bytesPerHistogramEntry
+	}
+	if o.superpixels > 0 {
+		perPixel += bytesPerSuperpixelPixel
+	}
+	if o.regionWeight {
+		perPixel += bytesPerRegionPixel
+	}
+	return int64(n) * perPixel
+}
+
+// workingSize returns the maximum width and height of the working image
+// that keeps the clustering within the memory budget.
+func (o *options) workingSize() int {
+	resizeTo := resizeTo
+	if o.stable {
+		resizeTo = stableResizeTo
+	}
+	if o.maxMemory <= 0 || o.memoryEstimate(resizeTo*resizeTo, false) <= o.maxMemory {
+		return resizeTo
+	}
+	size := int(math.Sqrt(float64(o.maxMemory) / float64(o.memoryEstimate(1, false))))
+	if size < minWorkingSize {
+		size = minWorkingSize
+	}
+	if size > resizeTo {
+		size = resizeTo
+	}
+	return size
+}
+
+// histogramFits returns whether the histogram of n pixels can be built
+// within the memory budget. The histogram is only a speedup, so it is the
+// first thing to go when memory is tight.
+func (o *options) histogramFits(n int) bool {
+	return o.maxMemory <= 0 || o.memoryEstimate(n, true) <= o.maxMemory
+}
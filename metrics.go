@@ -0,0 +1,54 @@
+package dominantcolor
+
+import (
+	"image"
+	"math"
+)
+
+// Precision of the histogram used to measure the complexity of an image.
+const complexityBits = 4
+
+// PaletteEntropy returns the Shannon entropy in bits of the distribution of
+// weights in colors. A palette dominated by a single color has an entropy
+// close to 0, while n equally weighted colors have an entropy of log2(n).
+// Weights are normalized, so they do not need to sum to 1.
+func PaletteEntropy(colors []Color) float64 {
+	weights := make([]float64, len(colors))
+	for i, c := range colors {
+		weights[i] = c.Weight
+	}
+	return entropy(weights)
+}
+
+// Complexity returns a score between 0 and 1 describing how many colors
+// are used in img. Simple images such as logos score close to 0 while busy
+// photographs score high. It is the entropy of the color histogram of the
+// image relative to the entropy of a uniform histogram.
+func Complexity(img image.Image, opts ...Option) float64 {
+	bins := Histogram(img, complexityBits, opts...)
+	weights := make([]float64, len(bins))
+	for i, b := range bins {
+		weights[i] = b.Count
+	}
+	return entropy(weights) / (3 * complexityBits)
+}
+
+func entropy(weights []float64) float64 {
+	var total float64
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	var h float64
+	for _, w := range weights {
+		if w > 0 {
+			p := w / total
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
@@ -0,0 +1,25 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// FindColor is like Find but returns the color in the color model m, such
+// as color.NRGBA64Model or color.CMYKModel.
+func FindColor(img image.Image, m color.Model, opts ...Option) color.Color {
+	return m.Convert(Find(img, opts...))
+}
+
+// FindNColor is like FindN but returns the colors in the color model m.
+func FindNColor(img image.Image, nClusters int, m color.Model, opts ...Option) []color.Color {
+	return convertColors(FindN(img, nClusters, opts...), m)
+}
+
+func convertColors(colors []color.RGBA, m color.Model) []color.Color {
+	converted := make([]color.Color, len(colors))
+	for i, c := range colors {
+		converted[i] = m.Convert(c)
+	}
+	return converted
+}
@@ -0,0 +1,626 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Option configures how colors are extracted from an image.
+type Option func(*options)
+
+type options struct {
+	// Multiplier of the normalized pixel coordinates when they are used as
+	// clustering features. Zero disables spatial clustering.
+	spatialWeight float64
+
+	// Approximate number of superpixels to segment the image into before
+	// clustering. Zero disables segmentation.
+	superpixels int
+
+	// Weigh clusters by their largest connected region instead of their
+	// total number of pixels.
+	regionWeight bool
+
+	// Multiplier of the weight of pixels on edges. One disables edge
+	// detection.
+	edgeWeight float64
+
+	// Number of high-order bits kept in each color channel.
+	quantizeBits int
+
+	// Approximate upper bound of memory allocated while clustering in
+	// bytes. Zero means no limit.
+	maxMemory int64
+
+	// Pixels with a lower alpha value are considered transparent.
+	minAlpha uint8
+
+	// Selects the pixels to analyze.
+	masker Masker
+
+	// Regions of the image to analyze or to ignore.
+	includeRegions []image.Rectangle
+	excludeRegions []image.Rectangle
+
+	// Color difference from the reference image of FindDiff or from the
+	// previous frame above which a pixel is analyzed.
+	diffTolerance float64
+
+	// Accumulate only the pixels that changed compared to the previous
+	// frames.
+	frameDiff bool
+
+	// Produce results independent of the resolution of the image.
+	stable bool
+
+	// Colors that are always among the centroids.
+	pinned []color.RGBA
+
+	// Ranges of hues in degrees to ignore.
+	excludeHues []hueRange
+
+	// Pick the most salient color instead of the heaviest one.
+	salience bool
+
+	// Algorithm used to group colors.
+	algorithm Algorithm
+
+	// Colors that Find must not return, and the minimum color difference
+	// from them.
+	avoid       []color.RGBA
+	avoidDeltaE float64
+
+	// Color space in which colors are clustered.
+	space ColorSpace
+
+	// Size in pixels at which vector images are rendered.
+	rasterSize int
+
+	// Count the exact colors of the image: resize without blending pixels
+	// and cluster the histogram of the image starting from its heaviest
+	// colors.
+	exact bool
+
+	// Colors of pixels to ignore.
+	ignore []color.RGBA
+
+	// Radius in pixels of the box blur of the working image. Zero
+	// disables blurring.
+	blur int
+
+	// How the starting centroids of KMean are picked.
+	seeding Seeding
+
+	// Replace centroids by the closest color that occurs in the image.
+	snap bool
+
+	// Range of HSL saturations of the colors Find may pick.
+	minSaturation, maxSaturation float64
+
+	// Number of pixels of the working image sampled for clustering. Zero
+	// clusters all pixels.
+	sampleSize int
+
+	// Analyze images at their own size.
+	noResize bool
+
+	// Reports the phases of the analysis.
+	spans SpanFunc
+
+	// Shrinks images to the working size instead of the default scalers.
+	resizer Resizer
+
+	// Fraction of the weight of each KMean cluster, farthest from its
+	// centroid, ignored when moving the centroid.
+	trim float64
+
+	// Starting centroids of KMean, such as the colors of a previous
+	// result.
+	seeds []color.RGBA
+
+	// Largest change of the share of the weight of any cluster between two
+	// KMean iterations at which the iterations stop. Zero waits for the
+	// centroids to stop moving.
+	weightTolerance float64
+
+	// Analyze the EXIF thumbnail of JPEG files instead of decoding them.
+	exifThumbnail bool
+
+	// Use the colors of translucent pixels before blending them with
+	// transparency.
+	unpremultiply bool
+
+	// Weights of the pixels of the image in row-major order, as given by a
+	// PixelSource. Nil weighs all pixels equally.
+	pixelWeights []float64
+
+	// The color features of the points are already in the color space,
+	// as read from the planes of an *image.YCbCr.
+	spacePoints bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		edgeWeight:    1,
+		quantizeBits:  8,
+		diffTolerance: diffToleranceDefault,
+		rasterSize:    resizeTo,
+		maxSaturation: 1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSpatialWeight includes the position of each pixel in the clustering.
+// Coordinates are normalized to the range of a color channel and multiplied
+// by w, so that with w=1 moving across the whole image costs as much as
+// moving from black to full intensity in a single channel. Larger values
+// make clusters correspond to coherent regions of the image rather than
+// colors scattered around it, so the most dominant color becomes the color
+// of the largest region.
+func WithSpatialWeight(w float64) Option {
+	return func(o *options) {
+		if w < 0 {
+			w = 0
+		}
+		o.spatialWeight = w
+	}
+}
+
+// WithSuperpixels segments the image into approximately n superpixels with
+// the SLIC algorithm and clusters their mean colors weighted by their area
+// instead of individual pixels. This is more robust to texture and noise
+// and yields palettes representative of regions in busy photographs.
+func WithSuperpixels(n int) Option {
+	return func(o *options) {
+		o.superpixels = n
+	}
+}
+
+// WithRegionWeight weighs each color by the area of its largest connected
+// region instead of its total number of pixels, so that a scattered or
+// dithered color does not beat a solid subject of the same total area.
+func WithRegionWeight() Option {
+	return func(o *options) {
+		o.regionWeight = true
+	}
+}
+
+// WithEdgeWeight multiplies the weight of pixels on edges by w, which must
+// be between 0 and 1. Edges are detected with a Sobel filter on the working
+// image. Anti-aliasing and JPEG ringing blend colors along edges, so
+// downweighting them yields cleaner colors on screenshots and compressed
+// images. A weight of 0 ignores edge pixels entirely.
+func WithEdgeWeight(w float64) Option {
+	return func(o *options) {
+		if w < 0 {
+			w = 0
+		} else if w > 1 {
+			w = 1
+		}
+		o.edgeWeight = w
+	}
+}
+
+// WithQuantizeBits keeps only the b high-order bits of each color channel
+// before clustering. This is a cheap denoiser that also greatly reduces the
+// number of distinct colors, which makes clustering faster. b is clamped to
+// the range [1, 8] and defaults to 8.
+func WithQuantizeBits(b int) Option {
+	return func(o *options) {
+		if b < 1 {
+			b = 1
+		} else if b > 8 {
+			b = 8
+		}
+		o.quantizeBits = b
+	}
+}
+
+// WithMaxMemory limits the memory allocated while clustering to
+// approximately n bytes, not counting the input image. The working image
+// is shrunk and the distinct color histogram is skipped as needed to stay
+// within the budget. This is useful for analyzing arbitrary uploads in
+// memory constrained environments.
+func WithMaxMemory(n int64) Option {
+	return func(o *options) {
+		o.maxMemory = n
+	}
+}
+
+// WithAlphaThreshold treats pixels with an alpha value lower than a as
+// transparent and ignores them. By default only fully transparent pixels
+// are ignored, which lets the halo of anti-aliased sprite edges leak into
+// the results.
+func WithAlphaThreshold(a uint8) Option {
+	return func(o *options) {
+		o.minAlpha = a
+	}
+}
+
+// WithMasker weighs each pixel by the mask computed by m, so that only the
+// selected part of the image, such as the subject of a photo, contributes
+// to the results.
+func WithMasker(m Masker) Option {
+	return func(o *options) {
+		o.masker = m
+	}
+}
+
+// WithIncludeRegions restricts the analysis to the pixels inside any of
+// rects, given in the coordinates of the image, such as faces found by an
+// external detector. It can be given multiple times to add more regions.
+func WithIncludeRegions(rects ...image.Rectangle) Option {
+	return func(o *options) {
+		o.includeRegions = append(o.includeRegions, rects...)
+	}
+}
+
+// WithExcludeRegions ignores the pixels inside any of rects, given in the
+// coordinates of the image. Exclusion takes precedence over inclusion.
+// It can be given multiple times to add more regions.
+func WithExcludeRegions(rects ...image.Rectangle) Option {
+	return func(o *options) {
+		o.excludeRegions = append(o.excludeRegions, rects...)
+	}
+}
+
+// WithDiffTolerance sets the CIE76 delta-E color difference from the
+// reference image above which FindDiff analyzes a pixel, or from the
+// previous frame with WithFrameDifferencing. It is also the difference
+// between the colors of consecutive frames above which FindGIF reports a
+// change. The default is 10.
+func WithDiffTolerance(deltaE float64) Option {
+	return func(o *options) {
+		o.diffTolerance = deltaE
+	}
+}
+
+// WithFrameDifferencing makes an Accumulator only accumulate the pixels
+// that differ from the previous frames by more than the tolerance set with
+// WithDiffTolerance. For streams from a fixed camera, this makes the
+// color of a moving subject dominate over the static background.
+func WithFrameDifferencing() Option {
+	return func(o *options) {
+		o.frameDiff = true
+	}
+}
+
+// WithResolutionStable makes the results independent of the resolution of
+// the image. The image is downscaled to 64 pixels by averaging the area
+// covered by each pixel instead of sampling the nearest pixel, and the
+// clusters start from the heaviest colors of its histogram instead of
+// randomly sampled pixels. The same scene at different sizes, such as a
+// photo and its thumbnail, then yields palettes whose matching colors
+// differ by a delta-E typically below 5, as long as both are at least 128
+// pixels large. Thumbnails smaller than that are already blurred too much
+// by their own resize.
+func WithResolutionStable() Option {
+	return func(o *options) {
+		o.stable = true
+	}
+}
+
+// WithPinnedColors makes colors, such as brand colors, always appear in
+// the results with their true coverage of the image. They are used as
+// cluster centroids that never move, so pixels closer to them than to any
+// other centroid are counted towards them. Pinned colors count towards the
+// number of clusters, which is raised if needed to fit all of them. It can
+// be given multiple times to add more colors.
+func WithPinnedColors(colors ...color.RGBA) Option {
+	return func(o *options) {
+		o.pinned = append(o.pinned, colors...)
+	}
+}
+
+// pinnedClusters returns new clusters for the pinned colors.
+func (o *options) pinnedClusters() kMeanClusterGroup {
+	clusters := make(kMeanClusterGroup, 0, len(o.pinned))
+	for _, c := range o.pinned {
+		v := vector{float64(c.R), float64(c.G), float64(c.B)}
+		if clusters.ContainsCentroid(v) {
+			continue
+		}
+		clusters = append(clusters, &kMeanCluster{centroid: v, pinned: true})
+	}
+	return clusters
+}
+
+// hueRange is a range of hues in degrees, going counterclockwise from
+// from to to.
+type hueRange struct {
+	from, to float64
+}
+
+// WithExcludeHueRange ignores the pixels whose hue is between fromDeg and
+// toDeg, going counterclockwise around the color wheel, so that the range
+// may wrap around 0°. For example, (330, 30) excludes reds and (75, 165)
+// excludes the greens of grass and foliage in real estate photos. Grays
+// and other colors too unsaturated to have a clear hue are kept. It can be
+// given multiple times to exclude more ranges.
+func WithExcludeHueRange(fromDeg, toDeg float64) Option {
+	return func(o *options) {
+		o.excludeHues = append(o.excludeHues, hueRange{normalizeHue(fromDeg), normalizeHue(toDeg)})
+	}
+}
+
+// excludedHue returns whether the hue h in [0, 360) is in any of the
+// excluded ranges.
+func (o *options) excludedHue(h float64) bool {
+	for _, r := range o.excludeHues {
+		if r.from <= r.to && h >= r.from && h <= r.to {
+			return true
+		}
+		if r.from > r.to && (h >= r.from || h <= r.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHue maps h in degrees to [0, 360).
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// WithSalience makes Find return the most visually attention-grabbing
+// color, as scored by Salience, instead of the color covering the largest
+// area. A smaller vivid region then wins over a large dull background.
+// Colors too bright, too dark or outside WithSaturationBounds are still
+// skipped. It has no effect on the order of colors returned by FindN and
+// FindWeight.
+func WithSalience() Option {
+	return func(o *options) {
+		o.salience = true
+	}
+}
+
+// WithAlgorithm selects the algorithm used to group the colors of the
+// image. The default is KMean.
+func WithAlgorithm(a Algorithm) Option {
+	return func(o *options) {
+		o.algorithm = a
+	}
+}
+
+// WithAvoidColors makes Find skip the colors that differ by less than
+// minDeltaE CIE76 delta-E from any of colors, such as the colors of the
+// toolbar of an app, so that the returned color stands out from them. If
+// every color of the image is too close, Find returns the one farthest from
+// the avoided colors.
+func WithAvoidColors(colors []color.RGBA, minDeltaE float64) Option {
+	return func(o *options) {
+		o.avoid = append(o.avoid, colors...)
+		o.avoidDeltaE = minDeltaE
+	}
+}
+
+// WithColorSpace selects the color space in which colors are clustered
+// with the KMean algorithm. The default is SpaceRGB.
+func WithColorSpace(s ColorSpace) Option {
+	return func(o *options) {
+		o.space = s
+	}
+}
+
+// WithRasterSize sets the size in pixels of the square that FindVector
+// renders vector images to fit in. The default is 256, the size images are
+// shrunk to before analysis. Smaller sizes are faster but blend the colors
+// of thin strokes.
+func WithRasterSize(px int) Option {
+	return func(o *options) {
+		if px < 1 {
+			px = 1
+		}
+		o.rasterSize = px
+	}
+}
+
+// WithIgnoreColors ignores the pixels of exactly one of colors, such as
+// the white background of documents. Their alpha is not compared. It can
+// be given multiple times to ignore more colors.
+func WithIgnoreColors(colors ...color.RGBA) Option {
+	return func(o *options) {
+		o.ignore = append(o.ignore, colors...)
+	}
+}
+
+// ignored returns whether pixels of color c are ignored.
+func (o *options) ignored(c color.RGBA) bool {
+	for _, i := range o.ignore {
+		if c.R == i.R && c.G == i.G && c.B == i.B {
+			return true
+		}
+	}
+	return false
+}
+
+// ForScreenshots tunes the analysis for screenshots of apps and other flat
+// designs, made of few exact colors on white or black backgrounds. Pixels
+// are resized without blending them, every distinct color is counted
+// exactly, and pure white and black pixels are ignored. Screenshots of
+// only white and black have no colors then.
+func ForScreenshots() Option {
+	return func(o *options) {
+		o.exact = true
+		o.ignore = append(o.ignore, color.RGBA{A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	}
+}
+
+// WithBlur blurs the working image with a box filter of the given radius
+// in pixels before clustering, which merges film grain, sensor noise and
+// fine textures into the colors of the surfaces they cover.
+func WithBlur(radius int) Option {
+	return func(o *options) {
+		o.blur = radius
+	}
+}
+
+// Seeding selects how the starting centroids of the KMean algorithm are
+// picked.
+type Seeding int
+
+const (
+	// RandomSeeding picks the colors of random pixels, as the original
+	// Chromium algorithm does. It is the default.
+	RandomSeeding Seeding = iota
+
+	// StratifiedSeeding samples a random pixel in each cell of a 16x16 grid
+	// laid over the image, and picks the most different colors among them.
+	// Seeds then cover the whole image, so that a small colorful subject
+	// in a corner is not missed, at the cost of favoring outlying colors.
+	StratifiedSeeding
+
+	// KMeansPlusPlus picks colors far from the colors already picked with
+	// a higher probability, as in the k-means++ algorithm. It is slower,
+	// but clusters converge to better and more varied colors.
+	KMeansPlusPlus
+)
+
+// WithSeeding selects how the starting centroids of the KMean algorithm
+// are picked. It has no effect with WithResolutionStable or
+// ForScreenshots, which start from the heaviest colors of the histogram.
+func WithSeeding(s Seeding) Option {
+	return func(o *options) {
+		o.seeding = s
+	}
+}
+
+// ForPhotos tunes the analysis for photographs. Colors are clustered in
+// CIE L*a*b* where distances match perceived differences, the image is
+// blurred to merge noise and textures, seeds are picked with k-means++,
+// and Find picks the most salient color.
+func ForPhotos() Option {
+	return func(o *options) {
+		o.space = SpaceLab
+		o.blur = 1
+		o.seeding = KMeansPlusPlus
+		o.salience = true
+	}
+}
+
+// WithPresentColors replaces each color found by the closest color, in RGB
+// space, that occurs in the image, so that palettes never contain
+// in-between colors synthesized by averaging. This matters for pixel art
+// and flat illustrations. Weights are unchanged and pinned colors are
+// kept. With WithBlur or WithSuperpixels, colors are taken from the
+// blurred or segmented image.
+func WithPresentColors() Option {
+	return func(o *options) {
+		o.snap = true
+	}
+}
+
+// WithSaturationBounds makes Find skip the colors whose HSL saturation,
+// between 0 and 1, is outside [min, max], in addition to the colors too
+// bright or too dark. For example, (0.4, 1) demands a colorful color and
+// (0, 0.15) a neutral one from the same clustering. If no color is
+// acceptable, Find returns the heaviest one.
+func WithSaturationBounds(min, max float64) Option {
+	return func(o *options) {
+		o.minSaturation, o.maxSaturation = min, max
+	}
+}
+
+// WithSampleSize clusters a random sample of n pixels of the working image
+// instead of all of them, which is faster for large numbers of clusters.
+// Pixels are sampled with probabilities proportional to their weights,
+// from alpha, masks and edges, so that weighted analyses remain
+// statistically correct. It has no effect with WithRegionWeight, which
+// needs every pixel.
+func WithSampleSize(n int) Option {
+	return func(o *options) {
+		o.sampleSize = n
+	}
+}
+
+// WithoutResize analyzes images at their own size instead of shrinking
+// them, for images that were already resized by the caller, such as the
+// thumbnail returned by WorkingImage. Analyzing large images without
+// resizing them is slow.
+func WithoutResize() Option {
+	return func(o *options) {
+		o.noResize = true
+	}
+}
+
+// WithSpans calls f at the start of each phase of the analysis, and the
+// function it returns at the end of the phase, so that services can see
+// where time goes per request without profiling. Phases may be skipped or
+// repeated, depending on the function and the options.
+func WithSpans(f SpanFunc) Option {
+	return func(o *options) {
+		o.spans = f
+	}
+}
+
+// WithResizer shrinks images larger than the working size with r instead
+// of the pure Go scalers of this package. r is not called for images that
+// are small enough or with WithoutResize.
+func WithResizer(r Resizer) Option {
+	return func(o *options) {
+		o.resizer = r
+	}
+}
+
+// WithTrim makes KMean ignore the points farthest from each centroid, up
+// to fraction of the weight of the cluster, when moving the centroid, so
+// that small overlays such as watermarks, logos or hot pixels do not drag
+// the colors found. The weights of the colors still count all their
+// pixels. A fraction of 0.05 is usually enough; it is capped at 0.5.
+func WithTrim(fraction float64) Option {
+	return func(o *options) {
+		o.trim = math.Max(0, math.Min(0.5, fraction))
+	}
+}
+
+// WithWeightTolerance stops the KMean iterations once the weight of every
+// cluster, as a fraction of the total weight, changes by less than
+// tolerance between two iterations, such as 0.002, instead of waiting for
+// the centroids to stop moving. On noisy photos centroids keep moving by a
+// channel value long after the clusters have settled, so this ends the
+// analysis earlier with nearly the same colors. Too large a tolerance
+// stops while a cluster is still slowly moving to another color.
+func WithWeightTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.weightTolerance = tolerance
+	}
+}
+
+// WithEXIFThumbnail makes DecodeAndFind analyze the thumbnail embedded in
+// the EXIF metadata of JPEG files, typically 160x120 pixels, instead of
+// decoding the whole image, which is many times faster for scans of photo
+// libraries at the cost of approximate colors. Bars padding the
+// thumbnail to another aspect ratio are ignored. Images without a
+// thumbnail, or with regions or a masker set, which are in the
+// coordinates of the whole image, are decoded in full.
+func WithEXIFThumbnail() Option {
+	return func(o *options) {
+		o.exifThumbnail = true
+	}
+}
+
+// Alpha below which ForLogos ignores pixels as part of the matte fringe.
+const logoMinAlpha = 0xe0
+
+// ForLogos tunes the analysis for logos and icons on transparent canvases.
+// Pixels are resized without blending them, every distinct color is
+// counted exactly, and the translucent pixels of anti-aliased edges, whose
+// colors are blended with transparency or with the matte color of the
+// exporting application, are ignored, so that they do not wash out the
+// colors of thin logos. Colors of the remaining translucent pixels are
+// taken before blending.
+func ForLogos() Option {
+	return func(o *options) {
+		o.exact = true
+		o.minAlpha = logoMinAlpha
+		o.unpremultiply = true
+	}
+}
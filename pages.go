@@ -0,0 +1,33 @@
+package dominantcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// PageRenderer renders a page of a document, such as a PDF, counting from
+// 0. It is typically backed by an external rendering library, and should
+// render thumbnails rather than full resolution pages since only the
+// working size is analyzed.
+type PageRenderer func(page int) (image.Image, error)
+
+// FindPages returns the dominant color of each of the nPages pages of a
+// document rendered by render, for color-coding thumbnails of documents.
+// It stops at the first error of render and returns the colors of the
+// previous pages along with the error. It returns an empty slice if nPages
+// is less than or equal to 0.
+func FindPages(nPages int, render PageRenderer, opts ...Option) ([]color.RGBA, error) {
+	if nPages <= 0 {
+		return []color.RGBA{}, nil
+	}
+	colors := make([]color.RGBA, 0, nPages)
+	for page := 0; page < nPages; page++ {
+		img, err := render(page)
+		if err != nil {
+			return colors, fmt.Errorf("dominantcolor: render page %d: %w", page, err)
+		}
+		colors = append(colors, Find(img, opts...))
+	}
+	return colors, nil
+}
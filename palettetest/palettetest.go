@@ -0,0 +1,49 @@
+// Package palettetest provides helpers for testing code that extracts
+// palettes with package dominantcolor.
+//
+// Extracted palettes may change slightly between versions of the
+// algorithm. Comparing them within a tolerance keeps such tests stable.
+package palettetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+// AssertPaletteClose fails the test if got and want do not have the same
+// number of colors or if any color of got differs by more than tolerance
+// CIE76 delta-E from its match in want. Colors are matched with
+// dominantcolor.MatchPalettes, so their order does not matter. Weights are
+// not compared.
+func AssertPaletteClose(t testing.TB, got, want []dominantcolor.Color, tolerance float64) {
+	t.Helper()
+	if msg := comparePalettes(got, want, tolerance); msg != "" {
+		t.Error(msg)
+	}
+}
+
+func comparePalettes(got, want []dominantcolor.Color, tolerance float64) string {
+	if len(got) != len(want) {
+		return fmt.Sprintf("palette has %d colors, want %d\ngot:  %s\nwant: %s",
+			len(got), len(want), format(got), format(want))
+	}
+	var lines []string
+	for _, p := range dominantcolor.MatchPalettes(got, want) {
+		if p.DeltaE > tolerance {
+			lines = append(lines, fmt.Sprintf("color %s differs from %s by delta-E %.2f, tolerance is %.2f",
+				dominantcolor.Hex(got[p.IndexA].RGBA), dominantcolor.Hex(want[p.IndexB].RGBA), p.DeltaE, tolerance))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func format(colors []dominantcolor.Color) string {
+	hexes := make([]string, len(colors))
+	for i, c := range colors {
+		hexes[i] = dominantcolor.Hex(c.RGBA)
+	}
+	return strings.Join(hexes, " ")
+}
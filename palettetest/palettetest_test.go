@@ -0,0 +1,33 @@
+package palettetest
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+func TestComparePalettes(t *testing.T) {
+	want := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 96, A: 255}, Weight: 0.6},
+		{RGBA: color.RGBA{B: 200, A: 255}, Weight: 0.4},
+	}
+	near := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 1, B: 198, A: 255}, Weight: 0.4},
+		{RGBA: color.RGBA{R: 229, G: 97, A: 255}, Weight: 0.6},
+	}
+	far := []dominantcolor.Color{
+		{RGBA: color.RGBA{R: 230, G: 96, A: 255}},
+		{RGBA: color.RGBA{G: 200, A: 255}},
+	}
+	if msg := comparePalettes(near, want, 2); msg != "" {
+		t.Errorf("Close palettes are reported different: %s", msg)
+	}
+	if msg := comparePalettes(far, want, 2); msg == "" {
+		t.Error("Far palettes are not reported different")
+	}
+	if msg := comparePalettes(want[:1], want, 2); msg == "" {
+		t.Error("Palettes of different length are not reported different")
+	}
+	AssertPaletteClose(t, near, want, 2)
+}
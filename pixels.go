@@ -0,0 +1,73 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// PixelSource provides the pixels of an image held by another library,
+// such as a video frame or a matrix of a computer vision library, so that
+// it can be analyzed without converting it to an image.Image first.
+type PixelSource interface {
+	// Size returns the width and height of the image.
+	Size() (width, height int)
+
+	// Pixels calls fn with the color and the weight of each pixel, in
+	// row-major order, width times height times. Pixels with zero weight
+	// are ignored, as are transparent pixels. Weights are usually 1.
+	Pixels(fn func(c color.NRGBA, weight float64))
+}
+
+// FindPixelsWeight is like FindWeight but analyzes the pixels of src, with
+// all the options of FindWeight. The pixels are resized to the working
+// size like images, and their weights are sampled with them. Negative and
+// non-finite weights are treated as zero. It returns an empty slice if src
+// is empty or provides a wrong number of pixels.
+func FindPixelsWeight(src PixelSource, nClusters int, opts ...Option) []Color {
+	img, weights := sourceImage(src)
+	if img == nil {
+		return []Color{}
+	}
+	return FindWeight(img, nClusters, append(opts, func(o *options) {
+		o.pixelWeights = weights
+	})...)
+}
+
+// FindPixels is like Find but analyzes the pixels of src, as described for
+// FindPixelsWeight.
+func FindPixels(src PixelSource, opts ...Option) color.RGBA {
+	return newOptions(opts).pick(FindPixelsWeight(src, nClustersDefault, opts...))
+}
+
+// sourceImage copies the pixels of src to an image and their weights to a
+// slice in row-major order. The pixels are appended as they come rather
+// than allocated from the size reported by src, which may not be trusted.
+// It returns a nil image if src is empty or provides a wrong number of
+// pixels.
+func sourceImage(src PixelSource) (*image.NRGBA, []float64) {
+	width, height := src.Size()
+	if width <= 0 || height <= 0 || width > math.MaxInt/4/height {
+		return nil, nil
+	}
+	n := width * height
+	var pix []uint8
+	var weights []float64
+	extra := false
+	src.Pixels(func(c color.NRGBA, weight float64) {
+		if len(weights) == n {
+			extra = true
+			return
+		}
+		if !(weight > 0) || math.IsInf(weight, 1) {
+			weight = 0
+		}
+		pix = append(pix, c.R, c.G, c.B, c.A)
+		weights = append(weights, weight)
+	})
+	if extra || len(weights) != n {
+		return nil, nil
+	}
+	img := &image.NRGBA{Pix: pix, Stride: 4 * width, Rect: image.Rect(0, 0, width, height)}
+	return img, weights
+}
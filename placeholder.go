@@ -0,0 +1,82 @@
+package dominantcolor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// Number of precomputed colors along a placeholder gradient.
+const placeholderSteps = 256
+
+// PlaceholderPNG returns a PNG image of size w x h filled with a soft
+// diagonal gradient through the colors of the palette, from the top left
+// to the bottom right corner. Each color spans a share of the gradient
+// proportional to its weight, so the placeholder resembles the image more
+// than its dominant color alone. Colors are blended as by Interpolate. If
+// all weights are zero, colors span equal shares.
+func PlaceholderPNG(colors []Color, w, h int) ([]byte, error) {
+	if len(colors) == 0 {
+		return nil, ErrEmptyPalette
+	}
+	if w <= 0 || h <= 0 {
+		return nil, ErrEmptyImage
+	}
+	ramp := placeholderRamp(colors)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	// Position along the diagonal, from 0 to 1, is (x/sx + y/sy) / 2.
+	sx, sy := math.Max(float64(w-1), 1), math.Max(float64(h-1), 1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := (float64(x)/sx + float64(y)/sy) / 2
+			img.SetRGBA(x, y, ramp[int(t*(placeholderSteps-1)+0.5)])
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// placeholderRamp returns the colors of the gradient of PlaceholderPNG at
+// placeholderSteps evenly spaced positions. Each color is pure at the
+// center of its share and eases into its neighbours, so the gradient has
+// no visible bands.
+func placeholderRamp(colors []Color) []color.RGBA {
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	// Centers of the shares of the colors.
+	stops := make([]float64, len(colors))
+	var start float64
+	for i, c := range colors {
+		share := 1 / float64(len(colors))
+		if total > 0 {
+			share = c.Weight / total
+		}
+		stops[i] = start + share/2
+		start += share
+	}
+	ramp := make([]color.RGBA, placeholderSteps)
+	j := 0
+	for i := range ramp {
+		t := float64(i) / (placeholderSteps - 1)
+		for j < len(stops)-1 && t > stops[j+1] {
+			j++
+		}
+		switch {
+		case t <= stops[0]:
+			ramp[i] = colors[0].RGBA
+		case j == len(stops)-1:
+			ramp[i] = colors[j].RGBA
+		default:
+			u := (t - stops[j]) / (stops[j+1] - stops[j])
+			ramp[i] = Interpolate(colors[j].RGBA, colors[j+1].RGBA, u*u*(3-2*u))
+		}
+	}
+	return ramp
+}
@@ -0,0 +1,115 @@
+package dominantcolor
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// Smallest CIE lightness and largest chroma of studio background
+	// pixels.
+	backgroundLightness = 90
+	backgroundChroma    = 8
+	// Fraction of the border of an image that must be background for it
+	// to be removed.
+	minBackgroundConfidence = 0.5
+)
+
+// ForProducts tunes the analysis for product photos on a white or near-white
+// studio background, as common in e-commerce. If most of the border of the
+// image is near-white, the near-white pixels connected to the border are
+// ignored, so that only the product is analyzed, including its own white
+// parts. Other images are analyzed in full. It replaces any Masker. Seeds
+// are picked with k-means++, which finds the colors of small products that
+// random seeds would miss among the ignored pixels.
+func ForProducts() Option {
+	return func(o *options) {
+		o.seeding = KMeansPlusPlus
+		o.masker = MaskerFunc(func(img image.Image) *image.Alpha {
+			mask, confidence := whiteBackground(WorkingImage(img))
+			if confidence < minBackgroundConfidence {
+				return nil
+			}
+			return mask
+		})
+	}
+}
+
+// FindProduct is like FindWeightNormalized with ForProducts, and also
+// returns the confidence that the image has a white studio background:
+// the fraction of its border that is near-white. The background is only
+// ignored with a confidence of at least 0.5. Weights are fractions of the
+// product.
+func FindProduct(img image.Image, nClusters int, opts ...Option) ([]Color, float64) {
+	if ValidateInput(img, nClusters) != nil {
+		return []Color{}, 0
+	}
+	// The mask of the working image is scaled to img.
+	mask, confidence := whiteBackground(WorkingImage(img, opts...))
+	opts = append([]Option{WithSeeding(KMeansPlusPlus)}, opts...)
+	if confidence >= minBackgroundConfidence {
+		opts = append(opts, WithMasker(MaskerFunc(func(image.Image) *image.Alpha { return mask })))
+	}
+	return FindWeightNormalized(img, nClusters, opts...), confidence
+}
+
+// whiteBackground returns a mask of img excluding the near-white pixels
+// connected to its border, and the fraction of the border of img that is
+// near-white. The border is the outermost 2% of the width and height of
+// img, and at least one pixel. Transparent pixels count as background.
+func whiteBackground(img image.Image) (*image.Alpha, float64) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	white := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := rgbaAt(img, bounds.Min.X+x, bounds.Min.Y+y)
+			if a == 0 {
+				white[y*width+x] = true
+				continue
+			}
+			c := rgbToLab(uint8(r*0xff/a), uint8(g*0xff/a), uint8(b*0xff/a)).LCh()
+			white[y*width+x] = c.L >= backgroundLightness && c.C <= backgroundChroma
+		}
+	}
+	// Flood the background from the near-white pixels of the border.
+	borderX := int(math.Max(1, float64(width)/50))
+	borderY := int(math.Max(1, float64(height)/50))
+	background := make([]bool, width*height)
+	var queue []int
+	var border, borderWhite int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= borderX && x < width-borderX && y >= borderY && y < height-borderY {
+				continue
+			}
+			border++
+			if i := y*width + x; white[i] {
+				borderWhite++
+				background[i] = true
+				queue = append(queue, i)
+			}
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		x, y := i%width, i/width
+		for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			if n[0] < 0 || n[0] >= width || n[1] < 0 || n[1] >= height {
+				continue
+			}
+			if j := n[1]*width + n[0]; white[j] && !background[j] {
+				background[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+	mask := image.NewAlpha(bounds)
+	for i, bg := range background {
+		if !bg {
+			mask.Pix[i/width*mask.Stride+i%width] = 0xff
+		}
+	}
+	return mask, float64(borderWhite) / float64(border)
+}
@@ -0,0 +1,65 @@
+package dominantcolor
+
+import (
+	"image"
+	"math"
+)
+
+// Color difference, in CIE76 delta-E, above which a pixel is not
+// represented by a palette.
+const unrepresentedDeltaE = 20
+
+// QualityReport measures how well a palette summarizes an image.
+type QualityReport struct {
+	// Mean color difference, in CIE76 delta-E, between the pixels and
+	// their closest palette color. It is +Inf for an empty palette.
+	MeanDeltaE float64
+	// Fraction of the pixels farther than a delta-E of 20 from every
+	// palette color, such as small accents merged into other colors.
+	Unrepresented float64
+}
+
+// Validate replaces each pixel of img by the closest color of result, a
+// palette found for img such as by FindWeight, and reports the color
+// differences, so that pipelines can flag images poorly summarized by their
+// palette for review or for analysis with more colors. Pixels are read from
+// the working image and weighted as by FindWeight with the same options.
+// If img is rejected by ValidateInput or has no pixels to analyze, both
+// fields of the report are NaN, so that it is not mistaken for a perfect
+// palette.
+func Validate(img image.Image, result []Color, opts ...Option) QualityReport {
+	if ValidateInput(img, 0) != nil {
+		return unmeasuredReport()
+	}
+	o := newOptions(opts)
+	mask := newWeightMask(img, o)
+	img = o.resize(img)
+	points := histogramPoints(imagePoints(img, mask.scaled(img.Bounds()), o))
+	palette := make([]lab, len(result))
+	for i, c := range result {
+		palette[i] = rgbToLab(c.R, c.G, c.B)
+	}
+	var sum, unrepresented, total float64
+	for _, p := range points {
+		c := p.v.RGBA()
+		l := rgbToLab(c.R, c.G, c.B)
+		d := math.Inf(1)
+		for _, pc := range palette {
+			d = math.Min(d, deltaE(l, pc))
+		}
+		sum += d * p.weight
+		if d > unrepresentedDeltaE {
+			unrepresented += p.weight
+		}
+		total += p.weight
+	}
+	if total == 0 {
+		return unmeasuredReport()
+	}
+	return QualityReport{MeanDeltaE: sum / total, Unrepresented: unrepresented / total}
+}
+
+// unmeasuredReport returns the report of an image without pixels.
+func unmeasuredReport() QualityReport {
+	return QualityReport{MeanDeltaE: math.NaN(), Unrepresented: math.NaN()}
+}
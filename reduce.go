@@ -0,0 +1,76 @@
+package dominantcolor
+
+import (
+	"math"
+	"sort"
+)
+
+// ReducePalette merges the colors of a large palette, such as one
+// accumulated over many images, until target colors remain, heaviest
+// first. The two colors whose merge least increases the weighted variance
+// of the palette in CIE L*a*b*, by Ward's criterion, are merged first into
+// their weighted mean color, which sums their weights. Heavy colors thus
+// absorb similar light ones before distinct colors are merged. Colors that
+// are never merged are kept exactly. If all weights are zero, colors count
+// equally and the result has zero weights.
+func ReducePalette(colors []Color, target int) []Color {
+	if target < 0 {
+		target = 0
+	}
+	type entry struct {
+		c      Color
+		lab    lab
+		weight float64
+	}
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	entries := make([]entry, len(colors))
+	for i, c := range colors {
+		w := c.Weight
+		if total == 0 {
+			w = 1
+		}
+		entries[i] = entry{c: c, lab: rgbToLab(c.R, c.G, c.B), weight: w}
+	}
+	for len(entries) > target && len(entries) > 1 {
+		bi, bj, best := 0, 1, math.Inf(1)
+		for i := range entries {
+			for j := i + 1; j < len(entries); j++ {
+				a, b := entries[i], entries[j]
+				var cost float64
+				if w := a.weight + b.weight; w > 0 {
+					d := deltaE(a.lab, b.lab)
+					cost = d * d * a.weight * b.weight / w
+				}
+				if cost < best {
+					bi, bj, best = i, j, cost
+				}
+			}
+		}
+		a, b := entries[bi], entries[bj]
+		merged := a
+		if w := a.weight + b.weight; w > 0 {
+			ta := a.weight / w
+			merged.lab = lab{
+				L: a.lab.L*ta + b.lab.L*(1-ta),
+				A: a.lab.A*ta + b.lab.A*(1-ta),
+				B: a.lab.B*ta + b.lab.B*(1-ta),
+			}
+		}
+		merged.weight += b.weight
+		merged.c = Color{RGBA: merged.lab.RGBA(), Weight: a.c.Weight + b.c.Weight}
+		entries[bi] = merged
+		entries = append(entries[:bj], entries[bj+1:]...)
+	}
+	if target == 0 {
+		entries = entries[:0]
+	}
+	reduced := make([]Color, len(entries))
+	for i, e := range entries {
+		reduced[i] = e.c
+	}
+	sort.SliceStable(reduced, func(i, j int) bool { return reduced[i].Weight > reduced[j].Weight })
+	return reduced
+}
@@ -0,0 +1,58 @@
+package dominantcolor
+
+// weighByLargestRegion replaces the weight of each cluster with the area of
+// the largest 4-connected region of points assigned to it, so that a color
+// scattered around the image weighs less than a solid region of the same
+// total area.
+func weighByLargestRegion(clusters kMeanClusterGroup, points []point, width, height int) {
+	if len(clusters) == 0 {
+		return
+	}
+	index := make(map[*kMeanCluster]int, len(clusters))
+	for i, c := range clusters {
+		index[c] = i
+	}
+	labels := make([]int, len(points))
+	for i, p := range points {
+		labels[i] = -1
+		if p.weight == 0 {
+			continue
+		}
+		labels[i] = index[clusters.Closest(p.v)]
+	}
+
+	largest := make([]float64, len(clusters))
+	visited := make([]bool, len(points))
+	var stack []int
+	for start, l := range labels {
+		if l < 0 || visited[start] {
+			continue
+		}
+		// Flood fill the region containing start.
+		var area float64
+		visited[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			area += points[i].weight
+			x, y := i%width, i/width
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= width || n[1] < 0 || n[1] >= height {
+					continue
+				}
+				j := n[1]*width + n[0]
+				if !visited[j] && labels[j] == l {
+					visited[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+		if area > largest[l] {
+			largest[l] = area
+		}
+	}
+	for i, c := range clusters {
+		c.weight = largest[i]
+	}
+}
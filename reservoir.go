@@ -0,0 +1,58 @@
+package dominantcolor
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+// reservoirSample returns n of points picked at random with probabilities
+// proportional to their weights, using the A-Res weighted reservoir
+// sampling algorithm. The weight of each picked point is set to the mean
+// weight of the picks, so that the total weight is preserved. Points with
+// zero weight are never picked.
+func reservoirSample(points []point, n int) []point {
+	rnd := rand.New(rand.NewSource(0))
+	reservoir := make(reservoirHeap, 0, n)
+	var total float64
+	for _, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		total += p.weight
+		// Keys are log(u)/w instead of u^(1/w) to avoid underflow.
+		key := math.Log(rnd.Float64()) / p.weight
+		switch {
+		case len(reservoir) < n:
+			heap.Push(&reservoir, keyedPoint{p, key})
+		case key > reservoir[0].key:
+			reservoir[0] = keyedPoint{p, key}
+			heap.Fix(&reservoir, 0)
+		}
+	}
+	sample := make([]point, len(reservoir))
+	for i, k := range reservoir {
+		sample[i] = k.point
+		sample[i].weight = total / float64(len(reservoir))
+	}
+	return sample
+}
+
+type keyedPoint struct {
+	point
+	key float64
+}
+
+// reservoirHeap is a min-heap of points by key.
+type reservoirHeap []keyedPoint
+
+func (h reservoirHeap) Len() int            { return len(h) }
+func (h reservoirHeap) Less(i, j int) bool  { return h[i].key < h[j].key }
+func (h reservoirHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *reservoirHeap) Push(x interface{}) { *h = append(*h, x.(keyedPoint)) }
+func (h *reservoirHeap) Pop() interface{} {
+	old := *h
+	k := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return k
+}
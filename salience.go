@@ -0,0 +1,15 @@
+package dominantcolor
+
+import "math"
+
+// Salience returns how much c is expected to attract attention, combining
+// its weight with its chroma and lightness in CIE LCh space. Vivid colors
+// of medium lightness score higher than grays, near-blacks and near-whites
+// of the same weight:
+//
+//	weight × (1 + 2·C*/100) × (1 − |L* − 50|/100)
+func Salience(c Color) float64 {
+	l := rgbToLab(c.R, c.G, c.B)
+	chroma := math.Hypot(l.A, l.B)
+	return c.Weight * (1 + 2*chroma/100) * (1 - math.Abs(l.L-50)/100)
+}
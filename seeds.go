@@ -0,0 +1,238 @@
+package dominantcolor
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Number of low-order bits of each channel ignored when grouping colors to
+// pick seeds from the histogram.
+const seedBinShift = 3
+
+// givenSeeds adds a cluster starting at each of seeds, converted to the
+// features of space, skipping colors that are already centroids.
+func givenSeeds(clusters kMeanClusterGroup, seeds []color.RGBA, space ColorSpace) kMeanClusterGroup {
+	for _, c := range seeds {
+		v := vector{float64(c.R), float64(c.G), float64(c.B)}
+		space.toFeature(&v)
+		if clusters.ContainsCentroid(v) {
+			continue
+		}
+		k := new(kMeanCluster)
+		k.SetCentroid(v)
+		clusters = append(clusters, k)
+	}
+	return clusters
+}
+
+// randomSeeds adds clusters until there are nCluster of them, picking
+// their starting points by randomly sampling the row-major grid of points.
+func randomSeeds(clusters kMeanClusterGroup, points []point, width, height, nCluster int) kMeanClusterGroup {
+	rnd := rand.New(rand.NewSource(0))
+	randomPoint := func() point {
+		x := rnd.Intn(width)
+		y := rnd.Intn(height)
+		return points[y*width+x]
+	}
+	// Pick a starting point for each cluster.
+	for len(clusters) < nCluster && len(points) != 0 {
+		// Try up to 10 times to find a unique color. If no unique color can be
+		// found, destroy this cluster.
+		colorUnique := false
+		for j := 0; j < maxSample; j++ {
+			p := randomPoint()
+			// Ignore transparent pixels.
+			if p.weight == 0 {
+				continue
+			}
+			// Check to see if we have seen this color before.
+			colorUnique = !clusters.ContainsCentroid(p.v)
+			// If we have a unique color set the center of the cluster to
+			// that color.
+			if colorUnique {
+				c := new(kMeanCluster)
+				c.SetCentroid(p.v)
+				clusters = append(clusters, c)
+				break
+			}
+		}
+		if !colorUnique {
+			break
+		}
+	}
+	return clusters
+}
+
+// histogramSeeds adds clusters until there are nCluster of them, picking
+// their starting points from the heaviest bins of a coarse color histogram
+// of points. Unlike random sampling, the result only depends on the
+// distribution of colors and not on the position or number of pixels.
+// Bins adjacent to an already picked bin are only used if there are not
+// enough other bins.
+func histogramSeeds(clusters kMeanClusterGroup, points []point, nCluster int) kMeanClusterGroup {
+	type bin struct {
+		key    [3]int
+		sum    vector
+		weight float64
+	}
+	index := make(map[[3]int]int)
+	var bins []bin
+	for _, p := range points {
+		if p.weight == 0 {
+			continue
+		}
+		key := seedBin(p.v)
+		i, ok := index[key]
+		if !ok {
+			i = len(bins)
+			index[key] = i
+			bins = append(bins, bin{key: key})
+		}
+		for f := range p.v {
+			bins[i].sum[f] += p.v[f] * p.weight
+		}
+		bins[i].weight += p.weight
+	}
+	sort.SliceStable(bins, func(i, j int) bool { return bins[i].weight > bins[j].weight })
+
+	adjacent := func(a, b [3]int) bool {
+		for c := range a {
+			if d := a[c] - b[c]; d < -1 || d > 1 {
+				return false
+			}
+		}
+		return true
+	}
+	picked := make([]bool, len(bins))
+	var keys [][3]int
+	for _, c := range clusters {
+		keys = append(keys, seedBin(c.centroid))
+	}
+	for pass := 0; pass < 2; pass++ {
+		for i, b := range bins {
+			if len(clusters) == nCluster {
+				return clusters
+			}
+			if picked[i] {
+				continue
+			}
+			if pass == 0 {
+				isAdjacent := false
+				for _, k := range keys {
+					isAdjacent = isAdjacent || adjacent(b.key, k)
+				}
+				if isAdjacent {
+					continue
+				}
+			}
+			var v vector
+			for f := range v {
+				v[f] = b.sum[f] / b.weight
+			}
+			for f := 0; f < 3; f++ {
+				v[f] = float64(int(v[f]))
+			}
+			picked[i] = true
+			keys = append(keys, b.key)
+			c := new(kMeanCluster)
+			c.SetCentroid(v)
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters
+}
+
+func seedBin(v vector) [3]int {
+	return [3]int{int(v[0]) >> seedBinShift, int(v[1]) >> seedBinShift, int(v[2]) >> seedBinShift}
+}
+
+// plusPlusSeeds adds clusters until there are nCluster of them, picking
+// their starting points among points with the k-means++ algorithm: each
+// point is picked with a probability proportional to its weight times its
+// squared distance to the closest cluster picked so far, which spreads
+// the seeds over the colors of the image. The first seed, if there is no
+// cluster yet, is picked with a probability proportional to weight.
+func plusPlusSeeds(clusters kMeanClusterGroup, points []point, nCluster int) kMeanClusterGroup {
+	rnd := rand.New(rand.NewSource(0))
+	scores := make([]float64, len(points))
+	for len(clusters) < nCluster {
+		var sum float64
+		for i, p := range points {
+			scores[i] = p.weight
+			if p.weight != 0 && len(clusters) != 0 {
+				scores[i] *= clusters.Closest(p.v).GetDistanceSqr(p.v)
+			}
+			sum += scores[i]
+		}
+		// All points are transparent or at a centroid already.
+		if sum == 0 {
+			break
+		}
+		r := rnd.Float64() * sum
+		picked := -1
+		for i, s := range scores {
+			if s == 0 {
+				continue
+			}
+			picked = i
+			if r -= s; r <= 0 {
+				break
+			}
+		}
+		c := new(kMeanCluster)
+		c.SetCentroid(points[picked].v)
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// Number of cells along each axis of the grid sampled by stratifiedSeeds.
+const seedGridSize = 16
+
+// stratifiedSeeds adds clusters until there are nCluster of them. A random
+// point is sampled in each cell of a coarse grid laid over the row-major
+// grid of points, and the seeds are picked among the samples one by one,
+// each time taking the sample farthest from the clusters picked so far.
+func stratifiedSeeds(clusters kMeanClusterGroup, points []point, width, height, nCluster int) kMeanClusterGroup {
+	rnd := rand.New(rand.NewSource(0))
+	var samples []vector
+	for cy := 0; cy < seedGridSize; cy++ {
+		y0, y1 := cy*height/seedGridSize, (cy+1)*height/seedGridSize
+		for cx := 0; cx < seedGridSize; cx++ {
+			x0, x1 := cx*width/seedGridSize, (cx+1)*width/seedGridSize
+			if x0 == x1 || y0 == y1 {
+				continue
+			}
+			// Try up to 10 times to find an opaque pixel in the cell.
+			for j := 0; j < maxSample; j++ {
+				p := points[(y0+rnd.Intn(y1-y0))*width+x0+rnd.Intn(x1-x0)]
+				if p.weight != 0 {
+					samples = append(samples, p.v)
+					break
+				}
+			}
+		}
+	}
+	for len(clusters) < nCluster && len(samples) != 0 {
+		farthest, distanceToFarthest := 0, -1.0
+		for i, v := range samples {
+			d := math.Inf(1)
+			if len(clusters) != 0 {
+				d = clusters.Closest(v).GetDistanceSqr(v)
+			}
+			if d > distanceToFarthest {
+				farthest, distanceToFarthest = i, d
+			}
+		}
+		// All samples are at a centroid already.
+		if distanceToFarthest == 0 {
+			break
+		}
+		c := new(kMeanCluster)
+		c.SetCentroid(samples[farthest])
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
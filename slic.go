@@ -0,0 +1,135 @@
+package dominantcolor
+
+import "math"
+
+const (
+	slicIterations  = 10
+	slicCompactness = 10
+)
+
+// segmentSuperpixels partitions the row-major grid of points into
+// approximately n superpixels using the SLIC algorithm and replaces the
+// color of every point with the mean color of its superpixel. Clustering
+// the result is equivalent to clustering the mean colors of the
+// superpixels weighted by their area.
+func segmentSuperpixels(points []point, width, height, n int) {
+	if n <= 0 || width*height == 0 {
+		return
+	}
+	if n > len(points) {
+		n = len(points)
+	}
+	// Grid interval between the initial centers.
+	step := int(math.Sqrt(float64(width*height) / float64(n)))
+	if step < 1 {
+		step = 1
+	}
+
+	type center struct {
+		lab  lab
+		x, y float64
+	}
+	labs := make([]lab, len(points))
+	for i, p := range points {
+		labs[i] = rgbToLab(uint8(p.v[0]), uint8(p.v[1]), uint8(p.v[2]))
+	}
+	// Images thinner than half a step still get a row or column of
+	// centers.
+	x0, y0 := step/2, step/2
+	if x0 >= width {
+		x0 = width / 2
+	}
+	if y0 >= height {
+		y0 = height / 2
+	}
+	var centers []center
+	for y := y0; y < height; y += step {
+		for x := x0; x < width; x += step {
+			centers = append(centers, center{labs[y*width+x], float64(x), float64(y)})
+		}
+	}
+
+	labels := make([]int, len(points))
+	distances := make([]float64, len(points))
+	spatialScale := float64(slicCompactness) / float64(step)
+	for iter := 0; iter < slicIterations; iter++ {
+		for i := range labels {
+			labels[i] = -1
+			distances[i] = math.Inf(1)
+		}
+		// Assign each point to the closest center in a 2S x 2S window.
+		for ci, c := range centers {
+			x0, x1 := int(c.x)-step, int(c.x)+step
+			y0, y1 := int(c.y)-step, int(c.y)+step
+			if x0 < 0 {
+				x0 = 0
+			}
+			if y0 < 0 {
+				y0 = 0
+			}
+			for y := y0; y <= y1 && y < height; y++ {
+				for x := x0; x <= x1 && x < width; x++ {
+					i := y*width + x
+					if points[i].weight == 0 {
+						continue
+					}
+					dc := deltaE(labs[i], c.lab)
+					dx, dy := float64(x)-c.x, float64(y)-c.y
+					ds := math.Sqrt(dx*dx+dy*dy) * spatialScale
+					d := dc*dc + ds*ds
+					if d < distances[i] {
+						distances[i] = d
+						labels[i] = ci
+					}
+				}
+			}
+		}
+		// Move each center to the mean of its points.
+		sums := make([]center, len(centers))
+		counts := make([]float64, len(centers))
+		for i, l := range labels {
+			if l < 0 {
+				continue
+			}
+			s := &sums[l]
+			s.lab.L += labs[i].L
+			s.lab.A += labs[i].A
+			s.lab.B += labs[i].B
+			s.x += float64(i % width)
+			s.y += float64(i / width)
+			counts[l]++
+		}
+		for ci := range centers {
+			if counts[ci] == 0 {
+				continue
+			}
+			s := sums[ci]
+			centers[ci] = center{
+				lab: lab{s.lab.L / counts[ci], s.lab.A / counts[ci], s.lab.B / counts[ci]},
+				x:   s.x / counts[ci],
+				y:   s.y / counts[ci],
+			}
+		}
+	}
+
+	// Replace colors by the mean RGB color of each superpixel.
+	sums := make([][3]float64, len(centers))
+	counts := make([]float64, len(centers))
+	for i, l := range labels {
+		if l < 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			sums[l][c] += points[i].v[c]
+		}
+		counts[l]++
+	}
+	for i, l := range labels {
+		if l < 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			points[i].v[c] = math.Floor(sums[l][c] / counts[l])
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// OKLab is a color in the OKLab perceptual color space. L ranges from 0
+// for black to 1 for white, and A and B are roughly within [-0.4, 0.4].
+// It implements color.Color.
+type OKLab struct {
+	L, A, B float64
+}
+
+// ToOKLab converts c to OKLab, ignoring its alpha.
+func ToOKLab(c color.RGBA) OKLab {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// RGBA implements color.Color. Out of gamut colors are clamped.
+func (c OKLab) RGBA() (r, g, b, a uint32) {
+	return toColor(c.sRGB()).RGBA()
+}
+
+func (c OKLab) sRGB() (r, g, b float64) {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+// HSLuv is a color in the HSLuv color space, a human friendly alternative
+// to HSL built on CIE LCh(uv). H is the hue in degrees, and S and L range
+// from 0 to 100. Unlike HSL, colors of equal L have equal perceived
+// lightness. It implements color.Color.
+type HSLuv struct {
+	H, S, L float64
+}
+
+// CIELUV constants.
+const (
+	luvEpsilon = 216.0 / 24389
+	luvKappa   = 24389.0 / 27
+	refU       = 0.19783000664283
+	refV       = 0.46831999493879
+)
+
+// Conversion matrix from CIE XYZ to linear sRGB.
+var xyzToRGB = [3][3]float64{
+	{3.240969941904521, -1.537383177570093, -0.498610760293},
+	{-0.96924363628087, 1.87596750150772, 0.041555057407175},
+	{0.055630079696993, -0.20397695888897, 1.056971514242878},
+}
+
+// ToHSLuv converts c to HSLuv, ignoring its alpha.
+func ToHSLuv(c color.RGBA) HSLuv {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+	x := 0.41239079926595*r + 0.35758433938387*g + 0.18048078840183*b
+	y := 0.21263900587151*r + 0.71516867876775*g + 0.072192315360733*b
+	z := 0.019330818715591*r + 0.11919477979462*g + 0.95053215224966*b
+
+	// XYZ to CIELUV
+	var l, u, v float64
+	if y <= luvEpsilon {
+		l = y * luvKappa
+	} else {
+		l = 116*math.Cbrt(y) - 16
+	}
+	if d := x + 15*y + 3*z; l > 0 && d > 0 {
+		u = 13 * l * (4*x/d - refU)
+		v = 13 * l * (9*y/d - refV)
+	}
+
+	// CIELUV to LCh(uv) to HSLuv
+	chroma := math.Hypot(u, v)
+	h := math.Atan2(v, u) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	if chroma < 1e-8 {
+		h = 0
+	}
+	switch {
+	case l > 99.9999999:
+		return HSLuv{H: h, S: 0, L: 100}
+	case l < 1e-8:
+		return HSLuv{H: h, S: 0, L: 0}
+	}
+	return HSLuv{H: h, S: chroma / maxChroma(l, h) * 100, L: l}
+}
+
+// RGBA implements color.Color. Out of gamut colors are clamped.
+func (c HSLuv) RGBA() (r, g, b, a uint32) {
+	return toColor(c.sRGB()).RGBA()
+}
+
+func (c HSLuv) sRGB() (r, g, b float64) {
+	l := c.L
+	var chroma float64
+	switch {
+	case l > 99.9999999:
+		l = 100
+	case l < 1e-8:
+		return 0, 0, 0
+	default:
+		chroma = maxChroma(l, c.H) * c.S / 100
+	}
+
+	// LCh(uv) to CIELUV to XYZ
+	hrad := c.H * math.Pi / 180
+	u, v := chroma*math.Cos(hrad), chroma*math.Sin(hrad)
+	varU := u/(13*l) + refU
+	varV := v/(13*l) + refV
+	var y float64
+	if l <= 8 {
+		y = l / luvKappa
+	} else {
+		y = math.Pow((l+16)/116, 3)
+	}
+	x := -(9 * y * varU) / ((varU-4)*varV - varU*varV)
+	z := (9*y - 15*varV*y - varV*x) / (3 * varV)
+	m := xyzToRGB
+	return m[0][0]*x + m[0][1]*y + m[0][2]*z,
+		m[1][0]*x + m[1][1]*y + m[1][2]*z,
+		m[2][0]*x + m[2][1]*y + m[2][2]*z
+}
+
+// maxChroma returns the largest chroma in LCh(uv) of a color of lightness
+// l and hue h in degrees that is inside the sRGB gamut.
+func maxChroma(l, h float64) float64 {
+	hrad := h * math.Pi / 180
+	sub1 := math.Pow(l+16, 3) / 1560896
+	sub2 := sub1
+	if sub1 <= luvEpsilon {
+		sub2 = l / luvKappa
+	}
+	min := math.Inf(1)
+	for _, m := range xyzToRGB {
+		m1, m2, m3 := m[0], m[1], m[2]
+		for t := 0.0; t <= 1; t++ {
+			top1 := (284517*m1 - 94839*m3) * sub2
+			top2 := (838422*m3+769860*m2+731718*m1)*l*sub2 - 769860*t*l
+			bottom := (632260*m3-126452*m2)*sub2 + 126452*t
+			slope, intercept := top1/bottom, top2/bottom
+			length := intercept / (math.Sin(hrad) - slope*math.Cos(hrad))
+			if length >= 0 && length < min {
+				min = length
+			}
+		}
+	}
+	return min
+}
+
+// toColor converts linear sRGB values to an opaque color.
+func toColor(r, g, b float64) color.RGBA {
+	return color.RGBA{R: delinearize(r), G: delinearize(g), B: delinearize(b), A: 0xff}
+}
+
+// OKLab returns c in OKLab.
+func (c Color) OKLab() OKLab {
+	return ToOKLab(c.RGBA)
+}
+
+// HSLuv returns c in HSLuv.
+func (c Color) HSLuv() HSLuv {
+	return ToHSLuv(c.RGBA)
+}
+
+// ColorSpace selects the color space in which colors are clustered.
+type ColorSpace int
+
+const (
+	// SpaceRGB clusters colors in sRGB. It is the default.
+	SpaceRGB ColorSpace = iota
+
+	// SpaceOKLab clusters colors in OKLab, where distances match perceived
+	// color differences much better than in sRGB.
+	SpaceOKLab
+
+	// SpaceYCbCr clusters colors in the YCbCr space of JPEG images and
+	// video, so that clusters match broadcast color semantics. FindWeight,
+	// and Find and FindN with it, read the samples of *image.YCbCr images,
+	// such as decoded JPEG files, straight into the clustering features
+	// without converting the pixels to sRGB, unless an option working on
+	// sRGB colors is set, such as WithQuantizeBits, WithExcludeHueRange,
+	// WithIgnoreColors, WithBlur, WithEdgeWeight, WithSuperpixels,
+	// WithRegionWeight, WithPresentColors or WithResizer. Other images are
+	// converted from sRGB.
+	SpaceYCbCr
+
+	// SpaceHSV clusters colors by hue, saturation and value. Hues are
+	// compared around the color wheel, so that reds on both sides of 0°
+	// fall in the same cluster.
+	SpaceHSV
+
+	// SpaceLab clusters colors in CIE L*a*b*.
+	SpaceLab
+)
+
+// Multipliers of OKLab and CIE L*a*b* coordinates in clustering features,
+// so that they span a range similar to sRGB channels.
+const (
+	okLabScale = 0xff
+	labScale   = 0xff / 100.0
+)
+
+// toFeatures converts the color features of points from sRGB to the color
+// space. The points are modified in place.
+func (s ColorSpace) toFeatures(points []point) {
+	if s == SpaceRGB {
+		return
+	}
+	for i := range points {
+		s.toFeature(&points[i].v)
+	}
+}
+
+func (s ColorSpace) toFeature(v *vector) {
+	switch s {
+	case SpaceOKLab:
+		c := ToOKLab(v.RGBA())
+		v[0], v[1], v[2] = c.L*okLabScale, c.A*okLabScale, c.B*okLabScale
+	case SpaceYCbCr:
+		y, cb, cr := color.RGBToYCbCr(uint8(v[0]), uint8(v[1]), uint8(v[2]))
+		v[0], v[1], v[2] = float64(y), float64(cb), float64(cr)
+	case SpaceHSV:
+		v[0], v[1], v[2] = hsvFeatures(v.RGBA())
+	case SpaceLab:
+		c := rgbToLab(uint8(v[0]), uint8(v[1]), uint8(v[2]))
+		v[0], v[1], v[2] = c.L*labScale, c.A*labScale, c.B*labScale
+	}
+}
+
+// fromFeature converts the color features of v from the color space back
+// to sRGB.
+func (s ColorSpace) fromFeature(v *vector) {
+	var c color.RGBA
+	switch s {
+	case SpaceOKLab:
+		c = toColor(OKLab{L: v[0] / okLabScale, A: v[1] / okLabScale, B: v[2] / okLabScale}.sRGB())
+	case SpaceYCbCr:
+		r, g, b := color.YCbCrToRGB(clampChannel(v[0]), clampChannel(v[1]), clampChannel(v[2]))
+		c = color.RGBA{R: r, G: g, B: b, A: 0xff}
+	case SpaceHSV:
+		c = hsvColor(v[0], v[1], v[2])
+	case SpaceLab:
+		c = lab{L: v[0] / labScale, A: v[1] / labScale, B: v[2] / labScale}.RGBA()
+	default:
+		return
+	}
+	v[0], v[1], v[2] = float64(c.R), float64(c.G), float64(c.B)
+}
+
+// hsvFeatures returns the value, and the chroma of c projected on the
+// directions of 0° and 90° hues. Unlike the hue angle itself, these
+// features make the euclidean distance and mean of colors respect the
+// circularity of hues.
+func hsvFeatures(c color.RGBA) (v, x, y float64) {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	chroma := max - min
+	h, _, _ := hsl(c)
+	h *= math.Pi / 180
+	return max, chroma * math.Cos(h), chroma * math.Sin(h)
+}
+
+// hsvColor is the inverse of hsvFeatures.
+func hsvColor(v, x, y float64) color.RGBA {
+	chroma := math.Min(math.Hypot(x, y), math.Max(v, 0))
+	h := normalizeHue(math.Atan2(y, x)*180/math.Pi) / 60
+	second := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = chroma, second
+	case 1:
+		r, g = second, chroma
+	case 2:
+		g, b = chroma, second
+	case 3:
+		g, b = second, chroma
+	case 4:
+		r, b = second, chroma
+	default:
+		r, b = chroma, second
+	}
+	m := v - chroma
+	return color.RGBA{R: clampChannel(r + m), G: clampChannel(g + m), B: clampChannel(b + m), A: 0xff}
+}
+
+// ycbcrSource returns img as an *image.YCbCr if its samples can be read
+// straight into the clustering features, without converting them to sRGB.
+func (o *options) ycbcrSource(img image.Image) (*image.YCbCr, bool) {
+	ycc, ok := img.(*image.YCbCr)
+	if !ok || o.space != SpaceYCbCr || o.algorithm != KMean {
+		return nil, false
+	}
+	// Options working on sRGB colors, and resizers other than the nearest
+	// neighbor the samples are read with, need the pixels in sRGB.
+	if o.quantizeBits < 8 || len(o.excludeHues) > 0 || len(o.ignore) > 0 ||
+		o.blur > 0 || o.edgeWeight < 1 || o.superpixels > 0 || o.regionWeight || o.snap ||
+		o.resizer != nil || o.stable && !o.exact {
+		return nil, false
+	}
+	return ycc, true
+}
+
+// findYCbCrClusters is like findClusters for SpaceYCbCr but reads the
+// samples of img straight into the clustering features.
+func findYCbCrClusters(img *image.YCbCr, nCluster int, o *options) (kMeanClusterGroup, float64) {
+	mask := newWeightMask(img, o)
+	bounds := img.Bounds()
+	if resized, ok := fitBounds(bounds, o.workingSize()); ok && !o.noResize {
+		bounds = resized
+	}
+	end := o.span(PhasePixels)
+	points := ycbcrPoints(img, bounds, mask.scaled(bounds), o)
+	end()
+	spaceOptions := *o
+	spaceOptions.spacePoints = true
+	width, height := bounds.Dx(), bounds.Dy()
+	clusters := clusterPoints(points, width, height, nCluster, nIterations, &spaceOptions)
+	return clusters, float64(width) * float64(height)
+}
+
+// ycbcrPoints returns the points of img resized to bounds with nearest
+// neighbor sampling, as the default resize does, in row-major order. Their
+// color features are the Y, Cb and Cr samples of the pixels.
+func ycbcrPoints(img *image.YCbCr, bounds image.Rectangle, mask *weightMask, o *options) []point {
+	width, height := bounds.Dx(), bounds.Dy()
+	points := make([]point, 0, width*height)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			src := mapPoint(image.Pt(x, y), bounds, img.Rect)
+			yi, ci := img.YOffset(src.X, src.Y), img.COffset(src.X, src.Y)
+			p := point{weight: mask.weight(x, y)}
+			p.v[0], p.v[1], p.v[2] = float64(img.Y[yi]), float64(img.Cb[ci]), float64(img.Cr[ci])
+			if o.spatialWeight > 0 {
+				p.v[3] = normalizedCoordinate(x-bounds.Min.X, width) * o.spatialWeight
+				p.v[4] = normalizedCoordinate(y-bounds.Min.Y, height) * o.spatialWeight
+			}
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -0,0 +1,45 @@
+package dominantcolor
+
+// Phase is a stage of the analysis of an image, reported to a SpanFunc.
+type Phase string
+
+const (
+	// PhaseDecode decodes the image, in DecodeAndFind.
+	PhaseDecode Phase = "decode"
+
+	// PhaseResize shrinks the image to the working image.
+	PhaseResize Phase = "resize"
+
+	// PhasePixels reads the pixels of the working image.
+	PhasePixels Phase = "pixels"
+
+	// PhaseInit picks the starting centroids of the clusters.
+	PhaseInit Phase = "init"
+
+	// PhaseIterations groups colors into clusters.
+	PhaseIterations Phase = "iterations"
+
+	// PhaseSelection picks the color returned by Find among the clusters.
+	PhaseSelection Phase = "selection"
+)
+
+// SpanFunc is called at the start of each phase of an analysis, and returns
+// a function called at its end. It can start and end the spans of a
+// distributed tracing system, such as OpenTelemetry:
+//
+//	dominantcolor.WithSpans(func(p dominantcolor.Phase) func() {
+//		_, span := tracer.Start(ctx, "dominantcolor."+string(p))
+//		return func() { span.End() }
+//	})
+type SpanFunc func(p Phase) (end func())
+
+// span starts a phase and returns the function ending it.
+func (o *options) span(p Phase) (end func()) {
+	if o.spans == nil {
+		return func() {}
+	}
+	if end := o.spans(p); end != nil {
+		return end
+	}
+	return func() {}
+}
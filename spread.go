@@ -0,0 +1,62 @@
+package dominantcolor
+
+import "math"
+
+// Largest number of passes of SpreadPalette over the pairs of colors.
+const spreadPasses = 100
+
+// SpreadPalette returns a copy of colors where entries closer than
+// minDeltaE, in CIE76 delta-E, to another are moved apart, so that colors
+// of an image palette stay distinguishable when assigned to chart series or
+// categories. Colors are moved in lightness and chroma only, keeping their
+// hue and so the hue order of the palette, and stay within the sRGB gamut.
+// Weights and the order of the colors are kept. Palettes with more colors
+// than fit in the gamut at minDeltaE are spread as far as possible.
+func SpreadPalette(colors []Color, minDeltaE float64) []Color {
+	spread := make([]Color, len(colors))
+	copy(spread, colors)
+	lchs := make([]lch, len(colors))
+	for i, c := range colors {
+		lchs[i] = rgbToLab(c.R, c.G, c.B).LCh()
+	}
+	for pass := 0; pass < spreadPasses; pass++ {
+		moved := false
+		for i := range lchs {
+			for j := i + 1; j < len(lchs); j++ {
+				a, b := &lchs[i], &lchs[j]
+				d := deltaE(a.Lab(), b.Lab())
+				if d >= minDeltaE {
+					continue
+				}
+				moved = true
+				// Move both colors away from each other by half of the
+				// missing difference, in the direction they already differ
+				// in, or in lightness for equal colors.
+				dl, dc := b.L-a.L, b.C-a.C
+				norm := math.Hypot(dl, dc)
+				if norm < 1e-6 {
+					dl, dc, norm = 1, 0, 1
+				}
+				step := (minDeltaE - d) / 2 / norm
+				*a = spreadMove(*a, -dl*step, -dc*step)
+				*b = spreadMove(*b, dl*step, dc*step)
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	for i := range spread {
+		c := lchs[i].Lab().RGBA()
+		spread[i].R, spread[i].G, spread[i].B = c.R, c.G, c.B
+	}
+	return spread
+}
+
+// spreadMove returns c with its lightness and chroma changed by dl and dc,
+// kept within the sRGB gamut.
+func spreadMove(c lch, dl, dc float64) lch {
+	c.L = math.Max(0, math.Min(100, c.L+dl))
+	c.C = math.Max(0, c.C+dc)
+	return fitGamut(c)
+}
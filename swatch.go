@@ -0,0 +1,118 @@
+package dominantcolor
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// EmojiSet selects the emojis used by Swatches and NearestEmoji.
+type EmojiSet int
+
+const (
+	// NoEmoji prints swatches as Unicode blocks colored with 24-bit ANSI
+	// escape codes, for terminals.
+	NoEmoji EmojiSet = iota
+	// Circles uses the colored circle emojis, such as 🔴 and 🔵.
+	Circles
+	// Hearts uses the colored heart emojis, such as ❤️ and 💙.
+	Hearts
+)
+
+// emojiColor is an emoji of each set with its color in the widely used
+// Twemoji designs.
+type emojiColor struct {
+	circle, heart string
+	color         color.RGBA
+}
+
+// Emojis of colors, followed by emojis of neutrals.
+var emojiColors = []emojiColor{
+	{"🔴", "❤️", color.RGBA{R: 0xdd, G: 0x2e, B: 0x44, A: 0xff}},
+	{"🟠", "🧡", color.RGBA{R: 0xf4, G: 0x90, B: 0x0c, A: 0xff}},
+	{"🟡", "💛", color.RGBA{R: 0xfd, G: 0xcb, B: 0x58, A: 0xff}},
+	{"🟢", "💚", color.RGBA{R: 0x78, G: 0xb1, B: 0x59, A: 0xff}},
+	{"🔵", "💙", color.RGBA{R: 0x55, G: 0xac, B: 0xee, A: 0xff}},
+	{"🟣", "💜", color.RGBA{R: 0xaa, G: 0x8e, B: 0xd6, A: 0xff}},
+	{"🟤", "🤎", color.RGBA{R: 0xc1, G: 0x69, B: 0x4f, A: 0xff}},
+	{"⚫", "🖤", color.RGBA{R: 0x31, G: 0x37, B: 0x3d, A: 0xff}},
+	{"⚪", "🤍", color.RGBA{R: 0xe6, G: 0xe7, B: 0xe8, A: 0xff}},
+}
+
+const (
+	// Number of emojis of colors at the start of emojiColors.
+	nChromaticEmojis = 7
+	// OKLab chroma below which colors are matched to neutral emojis.
+	emojiNeutralChroma = 0.04
+)
+
+// NearestEmoji returns the emoji of set whose color is the closest to c in
+// OKLab, which matches hues better than CIE L*a*b* for blues, or an empty
+// string for NoEmoji. Grays get a black or white emoji.
+func NearestEmoji(c color.RGBA, set EmojiSet) string {
+	if set != Circles && set != Hearts {
+		return ""
+	}
+	lab := ToOKLab(c)
+	candidates := emojiColors[:nChromaticEmojis]
+	if math.Hypot(lab.A, lab.B) < emojiNeutralChroma {
+		candidates = emojiColors[nChromaticEmojis:]
+	}
+	var nearest emojiColor
+	nearestDist := math.Inf(1)
+	for _, e := range candidates {
+		el := ToOKLab(e.color)
+		// Lightness differences count half, as emojis come in a single
+		// shade of each color.
+		dl, da, db := (lab.L-el.L)/2, lab.A-el.A, lab.B-el.B
+		if d := dl*dl + da*da + db*db; d < nearestDist {
+			nearest, nearestDist = e, d
+		}
+	}
+	if set == Hearts {
+		return nearest.heart
+	}
+	return nearest.circle
+}
+
+// Swatches returns colors on a single line, for chat bots and terminals
+// printing palettes inline. Each color is printed as a swatch followed by
+// its hex code and, if the colors have weights, its percentage as returned
+// by Percentages:
+//
+//	██ #C81E1E 60%  ██ #1E3CC8 40%
+//
+// Swatches are Unicode blocks colored with ANSI escape codes for NoEmoji,
+// or the nearest emojis of set otherwise, for chat services that do not
+// render escape codes.
+func Swatches(colors []Color, set EmojiSet) string {
+	percentages := percentLabels(colors)
+	parts := make([]string, len(colors))
+	for i, c := range colors {
+		swatch := NearestEmoji(c.RGBA, set)
+		if set == NoEmoji {
+			swatch = fmt.Sprintf("\x1b[38;2;%d;%d;%dm██\x1b[0m", c.R, c.G, c.B)
+		}
+		parts[i] = swatch + " " + Hex(c.RGBA) + percentages[i]
+	}
+	return strings.Join(parts, "  ")
+}
+
+// percentLabels returns the percentage of each color, as returned by
+// Percentages, after a space, or empty strings if the colors have no
+// weights.
+func percentLabels(colors []Color) []string {
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	labels := make([]string, len(colors))
+	if total == 0 {
+		return labels
+	}
+	for i, p := range Percentages(colors) {
+		labels[i] = fmt.Sprintf(" %d%%", p)
+	}
+	return labels
+}
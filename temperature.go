@@ -0,0 +1,36 @@
+package dominantcolor
+
+// Colors with a lower HSL saturation are considered neutral.
+const neutralSaturation = 0.15
+
+// Temperature is the split of a palette into warm, cool and neutral
+// colors.
+type Temperature struct {
+	// Total weight of red, orange and yellow hues.
+	Warm float64
+	// Total weight of green, blue and purple hues.
+	Cool float64
+	// Total weight of grays and colors too dark or too light to have a
+	// perceptible hue.
+	Neutral float64
+}
+
+// SplitTemperature returns the total weight of warm, cool and neutral
+// colors in a weighted palette. Hues from 330° to 90° are warm and the rest
+// are cool. For a palette returned by FindWeight, the fields are fractions
+// of the image area.
+func SplitTemperature(colors []Color) Temperature {
+	var t Temperature
+	for _, c := range colors {
+		h, s, l := hsl(c.RGBA)
+		switch {
+		case s < neutralSaturation || l < 0.05 || l > 0.95:
+			t.Neutral += c.Weight
+		case h >= 330 || h < 90:
+			t.Warm += c.Weight
+		default:
+			t.Cool += c.Weight
+		}
+	}
+	return t
+}
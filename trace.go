@@ -0,0 +1,145 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Reasons why Find did not pick a candidate color.
+const (
+	ReasonAvoided    = "too close to an avoided color"
+	ReasonTooBright  = "too bright"
+	ReasonTooDark    = "too dark"
+	ReasonSaturation = "saturation out of bounds"
+	ReasonOutranked  = "outranked by the picked color"
+)
+
+// Candidate is a color Find considered, as reported by FindTrace.
+type Candidate struct {
+	Color
+
+	// Score used to rank the candidates: the weight, or the salience with
+	// WithSalience.
+	Score float64
+
+	// Whether Find picked the candidate.
+	Picked bool
+
+	// Reason why the candidate was not picked, empty if it was picked.
+	Reason string
+}
+
+// FindTrace is like Find but also returns every candidate color in their
+// order of dominance, with the reasons why they were not picked, to debug
+// surprising picks in production logs.
+func FindTrace(img image.Image, opts ...Option) (color.RGBA, []Candidate) {
+	return newOptions(opts).evaluate(FindWeight(img, nClustersDefault, opts...))
+}
+
+// evaluate returns the color Find picks among colors sorted by weight and
+// the evaluation of each of them.
+func (o *options) evaluate(colors []Color) (color.RGBA, []Candidate) {
+	defer o.span(PhaseSelection)()
+	trace := make([]Candidate, len(colors))
+	for i, c := range colors {
+		trace[i] = Candidate{Color: c, Score: c.Weight}
+		if o.salience {
+			trace[i].Score = Salience(c)
+		}
+	}
+	o.markAvoided(trace)
+	picked := -1
+	if o.salience {
+		for i := range trace {
+			c := &trace[i]
+			if c.Reason != "" {
+				continue
+			}
+			if c.Reason = o.rejection(c.RGBA); c.Reason == "" && (picked < 0 || c.Score > trace[picked].Score) {
+				picked = i
+			}
+		}
+	} else {
+		// Loop through the clusters to figure out which cluster has an
+		// appropriate color. Skip any that are too bright/dark and go in
+		// order of weight.
+		for i := range trace {
+			c := &trace[i]
+			if c.Reason != "" {
+				continue
+			}
+			if picked >= 0 {
+				c.Reason = ReasonOutranked
+				continue
+			}
+			if c.Reason = o.rejection(c.RGBA); c.Reason == "" {
+				picked = i
+			}
+		}
+	}
+	// We haven't found a valid color, fall back to the heaviest one that is
+	// not avoided.
+	for i := 0; picked < 0 && i < len(trace); i++ {
+		if trace[i].Reason != ReasonAvoided {
+			picked = i
+		}
+	}
+	if picked < 0 {
+		return color.RGBA{}, trace
+	}
+	for i := range trace {
+		if i != picked && trace[i].Reason == "" {
+			trace[i].Reason = ReasonOutranked
+		}
+	}
+	trace[picked].Picked = true
+	trace[picked].Reason = ""
+	return trace[picked].RGBA, trace
+}
+
+// markAvoided sets the reason of the candidates too close to an avoided
+// color. If all of them are, only the farthest one is kept.
+func (o *options) markAvoided(trace []Candidate) {
+	if len(o.avoid) == 0 || len(trace) == 0 {
+		return
+	}
+	farthest, maxDistance := 0, -1.0
+	kept := false
+	for i := range trace {
+		distance := math.Inf(1)
+		for _, a := range o.avoid {
+			distance = math.Min(distance, DeltaE(trace[i].RGBA, a))
+		}
+		if distance < o.avoidDeltaE {
+			trace[i].Reason = ReasonAvoided
+		} else {
+			kept = true
+		}
+		if distance > maxDistance {
+			farthest, maxDistance = i, distance
+		}
+	}
+	if !kept {
+		trace[farthest].Reason = ""
+	}
+}
+
+// rejection returns the reason why Find may not pick c as the dominant
+// color, or an empty string if it may.
+func (o *options) rejection(c color.RGBA) string {
+	// Sum the RGB components to determine if the color is too bright or too dark.
+	summedColor := uint16(c.R) + uint16(c.G) + uint16(c.B)
+	switch {
+	case summedColor >= maxBrightness:
+		return ReasonTooBright
+	case summedColor <= minDarkness:
+		return ReasonTooDark
+	}
+	if o.minSaturation > 0 || o.maxSaturation < 1 {
+		if _, s, _ := hsl(c); s < o.minSaturation || s > o.maxSaturation {
+			return ReasonSaturation
+		}
+	}
+	return ""
+}
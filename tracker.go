@@ -0,0 +1,93 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// Largest color difference, in CIE76 delta-E, between a cluster and the
+// cluster of the previous frames it continues.
+const trackDistance = 25
+
+// Number of frames a cluster can be missing before its ID is retired.
+const trackPatience = 5
+
+// TrackedColor is a dominant color of a frame with the ID of the cluster
+// it belongs to across frames.
+type TrackedColor struct {
+	Color
+	ID int
+}
+
+// Tracker finds the dominant colors of consecutive frames, such as those
+// of a video, and assigns each color the ID of the matching color of the
+// previous frames, so that consumers can animate the transitions of each
+// cluster. It is not safe for concurrent use.
+type Tracker struct {
+	nClusters int
+	opts      []Option
+	tracks    []track
+	nextID    int
+}
+
+// track is a cluster followed across frames.
+type track struct {
+	id     int
+	color  color.RGBA
+	missed int
+}
+
+// NewTracker returns a Tracker finding nClusters colors in each frame with
+// the given options.
+func NewTracker(nClusters int, opts ...Option) *Tracker {
+	return &Tracker{nClusters: nClusters, opts: opts}
+}
+
+// Update returns the dominant colors of the next frame as returned by
+// FindWeight, with their IDs. Colors are matched to the clusters of the
+// previous frames so that the sum of their color differences is minimal,
+// and keep the ID of their match unless it differs by more than a delta-E
+// of 25. Unmatched colors get new IDs. A cluster missing from up to 5
+// frames keeps its ID, so that colors flickering in and out of the
+// results do not get a new ID each time.
+func (t *Tracker) Update(frame image.Image) []TrackedColor {
+	colors := FindWeight(frame, t.nClusters, t.opts...)
+	previous := make([]Color, len(t.tracks))
+	for i, tr := range t.tracks {
+		previous[i] = Color{RGBA: tr.color}
+	}
+	matched := make([]bool, len(t.tracks))
+	tracked := make([]TrackedColor, len(colors))
+	for i, c := range colors {
+		tracked[i] = TrackedColor{Color: c, ID: -1}
+	}
+	for _, p := range MatchPalettes(colors, previous) {
+		if p.DeltaE > trackDistance {
+			continue
+		}
+		tr := &t.tracks[p.IndexB]
+		tracked[p.IndexA].ID = tr.id
+		tr.color, tr.missed = colors[p.IndexA].RGBA, 0
+		matched[p.IndexB] = true
+	}
+	// Retire clusters missing for too long.
+	tracks := t.tracks[:0]
+	for i, tr := range t.tracks {
+		if !matched[i] {
+			tr.missed++
+		}
+		if tr.missed <= trackPatience {
+			tracks = append(tracks, tr)
+		}
+	}
+	t.tracks = tracks
+	for i, c := range tracked {
+		if c.ID >= 0 {
+			continue
+		}
+		tracked[i].ID = t.nextID
+		t.tracks = append(t.tracks, track{id: t.nextID, color: c.RGBA})
+		t.nextID++
+	}
+	return tracked
+}
@@ -0,0 +1,79 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// TransferPalette returns a copy of dst recolored to match the colors of
+// src, in the manner of Reinhard et al.'s color transfer: the colors of
+// dst are shifted and scaled in CIE L*a*b* so that their mean and standard
+// deviation on each axis match those of src. Statistics are computed from
+// the working images analyzed with the given options, so masks and
+// regions select the pixels each image contributes, while every pixel of
+// dst is recolored. Alpha is kept. It returns nil if either image is
+// invalid.
+func TransferPalette(src, dst image.Image, opts ...Option) *image.NRGBA {
+	if ValidateInput(src, 0) != nil || ValidateInput(dst, 0) != nil {
+		return nil
+	}
+	o := newOptions(opts)
+	srcMean, srcStd := labStats(src, o)
+	dstMean, dstStd := labStats(dst, o)
+	var scale [3]float64
+	for i := range scale {
+		scale[i] = 1
+		if dstStd[i] > 0 {
+			scale[i] = srcStd[i] / dstStd[i]
+		}
+	}
+	bounds := dst.Bounds()
+	out := image.NewNRGBA(bounds)
+	// Images have few distinct colors compared to their pixels.
+	cache := make(map[color.NRGBA]color.NRGBA)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(dst.At(x, y)).(color.NRGBA)
+			t, ok := cache[c]
+			if !ok {
+				v := rgbToLab(c.R, c.G, c.B)
+				l := [3]float64{v.L, v.A, v.B}
+				for i := range l {
+					l[i] = (l[i]-dstMean[i])*scale[i] + srcMean[i]
+				}
+				r := lab{L: math.Max(0, math.Min(100, l[0])), A: l[1], B: l[2]}.RGBA()
+				t = color.NRGBA{R: r.R, G: r.G, B: r.B, A: c.A}
+				cache[c] = t
+			}
+			out.SetNRGBA(x, y, t)
+		}
+	}
+	return out
+}
+
+// labStats returns the weighted mean and standard deviation of the
+// L*, a* and b* coordinates of the colors of the working image of img.
+func labStats(img image.Image, o *options) (mean, std [3]float64) {
+	mask := newWeightMask(img, o)
+	img = o.resize(img)
+	var sum, sumSq [3]float64
+	var total float64
+	for _, p := range histogramPoints(imagePoints(img, mask.scaled(img.Bounds()), o)) {
+		c := p.v.RGBA()
+		v := rgbToLab(c.R, c.G, c.B)
+		for i, x := range [3]float64{v.L, v.A, v.B} {
+			sum[i] += p.weight * x
+			sumSq[i] += p.weight * x * x
+		}
+		total += p.weight
+	}
+	if total == 0 {
+		return mean, std
+	}
+	for i := range mean {
+		mean[i] = sum[i] / total
+		std[i] = math.Sqrt(math.Max(0, sumSq[i]/total-mean[i]*mean[i]))
+	}
+	return mean, std
+}
@@ -0,0 +1,41 @@
+package dominantcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Rasterizer renders vector images, such as SVG logos, so that their
+// colors can be analyzed. This package does not include any rasterizer.
+type Rasterizer interface {
+	// Rasterize renders the vector image read from r so that it fits in a
+	// square of size pixels.
+	Rasterize(r io.Reader, size int) (image.Image, error)
+}
+
+// RasterFunc is an adapter to allow the use of ordinary functions as
+// Rasterizers.
+type RasterFunc func(r io.Reader, size int) (image.Image, error)
+
+// Rasterize returns f(r, size).
+func (f RasterFunc) Rasterize(r io.Reader, size int) (image.Image, error) {
+	return f(r, size)
+}
+
+// FindVector returns the dominant color of the vector image read from r,
+// rendered by rasterize at the size set with WithRasterSize. It returns
+// any error of the rasterizer, or the error of ValidateInput for the
+// rendered image.
+func FindVector(r io.Reader, rasterize Rasterizer, opts ...Option) (color.RGBA, error) {
+	o := newOptions(opts)
+	img, err := rasterize.Rasterize(r, o.rasterSize)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("dominantcolor: rasterize: %w", err)
+	}
+	if err := ValidateInput(img, 0); err != nil {
+		return color.RGBA{}, err
+	}
+	return Find(img, opts...), nil
+}
@@ -0,0 +1,75 @@
+package dominantcolor
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// Alert reports that the colors of a watched stream of images started or
+// stopped deviating from the baseline.
+type Alert struct {
+	// Index of the image in the stream, starting from 0.
+	Frame int
+
+	// Whether the image deviates from the baseline. It is false when the
+	// colors return to the baseline after deviating.
+	Deviating bool
+
+	// Distance of the colors of the image to the baseline, as returned by
+	// PaletteDistance, and the colors themselves.
+	Distance float64
+	Colors   []Color
+}
+
+// Watcher compares a stream of images, such as the frames of a webcam
+// pointed at a status light, to a baseline palette for visual monitoring.
+// It is not safe for concurrent use.
+type Watcher struct {
+	baseline  []Color
+	threshold float64
+	opts      []Option
+	frame     int
+	deviating bool
+}
+
+// NewWatcher returns a Watcher comparing images to baseline, with colors
+// found with the given options. Images deviate from the baseline when the
+// PaletteDistance of their colors to baseline exceeds threshold. For a
+// baseline of a single color, this is the delta-E of the dominant color.
+func NewWatcher(baseline []Color, threshold float64, opts ...Option) *Watcher {
+	return &Watcher{baseline: baseline, threshold: threshold, opts: opts}
+}
+
+// Check compares the next image of the stream to the baseline. It returns
+// an alert and true if the image starts deviating from the baseline, or
+// stops deviating after previous images did. Invalid images are counted
+// as deviating.
+func (w *Watcher) Check(img image.Image) (Alert, bool) {
+	colors := FindWeight(img, len(w.baseline), w.opts...)
+	a := Alert{Frame: w.frame, Distance: PaletteDistance(colors, w.baseline), Colors: colors}
+	a.Deviating = a.Distance > w.threshold
+	w.frame++
+	if a.Deviating == w.deviating {
+		return Alert{}, false
+	}
+	w.deviating = a.Deviating
+	return a, true
+}
+
+// Watch checks every frame of src and calls alert with the alerts. It
+// returns any error returned by src other than io.EOF.
+func (w *Watcher) Watch(src FrameSource, alert func(Alert)) error {
+	for {
+		frame, _, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if a, ok := w.Check(frame); ok {
+			alert(a)
+		}
+	}
+}
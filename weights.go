@@ -0,0 +1,139 @@
+package dominantcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// FindWeightNormalized is like FindWeight but the weights are fractions of
+// the returned colors instead of the whole image, so that they sum to 1
+// even when transparent or ignored pixels are not counted.
+func FindWeightNormalized(img image.Image, nClusters int, opts ...Option) []Color {
+	return Normalize(FindWeight(img, nClusters, opts...))
+}
+
+// Normalize returns a copy of colors with weights scaled so that they sum
+// to 1. Colors with zero total weight are returned unchanged.
+func Normalize(colors []Color) []Color {
+	normalized := make([]Color, len(colors))
+	copy(normalized, colors)
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	if total == 0 {
+		return normalized
+	}
+	for i := range normalized {
+		normalized[i].Weight /= total
+	}
+	return normalized
+}
+
+// Percentages returns the weights of colors, relative to their sum, as
+// whole percentages that always add up to 100. Rounding errors are
+// assigned to the weights with the largest remainders, so that displays do
+// not show totals like 99% or 101%. It returns zeros if the total weight
+// is zero.
+func Percentages(colors []Color) []int {
+	percentages := make([]int, len(colors))
+	var total float64
+	for _, c := range colors {
+		total += c.Weight
+	}
+	if total == 0 {
+		return percentages
+	}
+	remainders := make([]float64, len(colors))
+	left := 100
+	for i, c := range colors {
+		p := c.Weight / total * 100
+		percentages[i] = int(math.Floor(p))
+		remainders[i] = p - math.Floor(p)
+		left -= percentages[i]
+	}
+	order := make([]int, len(colors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]] > remainders[order[j]] })
+	for i := 0; left > 0 && i < len(order); i++ {
+		percentages[order[i]]++
+		left--
+	}
+	return percentages
+}
+
+// ColorCount is a color with the estimated number of pixels it covers.
+type ColorCount struct {
+	color.RGBA
+	Pixels int64
+}
+
+// FindCounts is like FindWeight but returns the estimated number of pixels
+// of each color in img at its original resolution, instead of a fraction
+// of the working image, for analytics reporting pixel counts. Counts of
+// pixels partially selected by a mask are weighted accordingly.
+func FindCounts(img image.Image, nClusters int, opts ...Option) []ColorCount {
+	colors := FindWeight(img, nClusters, opts...)
+	counts := make([]ColorCount, len(colors))
+	for i, c := range colors {
+		counts[i] = ColorCount{RGBA: c.RGBA, Pixels: PixelCount(c, img.Bounds())}
+	}
+	return counts
+}
+
+// PixelCount returns the estimated number of pixels covered by c, as
+// returned by FindWeight, in an image with the given bounds.
+func PixelCount(c Color, bounds image.Rectangle) int64 {
+	return int64(math.Round(c.Weight * float64(bounds.Dx()) * float64(bounds.Dy())))
+}
+
+// Coverage returns the total weight of the colors within a color
+// difference of tolerance, in CIE76 delta-E, from c. With colors returned
+// by FindWeight, it is the fraction of the image covered by colors similar
+// to c.
+func Coverage(colors []Color, c color.RGBA, tolerance float64) float64 {
+	var coverage float64
+	for _, cc := range colors {
+		if DeltaE(cc.RGBA, c) <= tolerance {
+			coverage += cc.Weight
+		}
+	}
+	return coverage
+}
+
+// Number of clusters FindCovering picks colors from.
+const coveringClusters = 16
+
+// FindCovering returns the smallest set of dominant colors, heaviest
+// first, whose weights add up to at least coverage, a fraction between 0
+// and 1 of the opaque pixels of img. Colors are picked among 16 clusters,
+// so a coverage close to 1 may not be reached by images with many colors,
+// in which case all 16 are returned. Weights are normalized as by
+// FindWeightNormalized.
+func FindCovering(img image.Image, coverage float64, opts ...Option) []Color {
+	colors := FindWeightNormalized(img, coveringClusters, opts...)
+	for i, total := range CumulativeWeights(colors) {
+		if total >= coverage {
+			return colors[:i+1]
+		}
+	}
+	return colors
+}
+
+// CumulativeWeights returns the sum of the weights of each color and the
+// colors before it. For colors returned by FindWeight, these are the
+// fractions of the image covered by the first colors, so that palettes
+// can be cut at the first colors covering 90% of the image.
+func CumulativeWeights(colors []Color) []float64 {
+	cumulative := make([]float64, len(colors))
+	var total float64
+	for i, c := range colors {
+		total += c.Weight
+		cumulative[i] = total
+	}
+	return cumulative
+}